@@ -0,0 +1,23 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"llm-aggregator/internal/config"
+)
+
+// runConfig handles config print
+func runConfig(cfg *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New("usage: config print")
+	}
+
+	if cfg.File() != "" {
+		fmt.Printf("# Loaded from %s and environment variables\n", cfg.File())
+	} else {
+		fmt.Println("# Loaded from defaults and environment variables")
+	}
+	fmt.Print(cfg.String())
+	return nil
+}
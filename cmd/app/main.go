@@ -18,25 +18,35 @@
 package main
 
 import (
-	"context"
+	"flag"
 	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
-	"go.uber.org/zap"
-
-	"llm-aggregator/internal/common"
 	"llm-aggregator/internal/config"
-	"llm-aggregator/internal/database"
 	"llm-aggregator/internal/logger"
-	"llm-aggregator/internal/router"
-	"llm-aggregator/internal/server"
 
 	_ "llm-aggregator/docs" // Swagger documentation
 )
 
+// command is a CLI subcommand. run receives the loaded config and the arguments after the command name.
+type command struct {
+	name  string
+	usage string
+	run   func(cfg *config.Config, args []string) error
+}
+
+var commands = []command{
+	{"serve", "serve                       Start the HTTP server (default)", runServe},
+	{"migrate", "migrate up|status           Apply pending or list migrations\n" +
+		"  migrate down [N]            Roll back the last N migrations (default 1)\n" +
+		"  migrate to <version>        Migrate up or down to a version\n" +
+		"  migrate create <name>       Create <timestamp>_<name>.up.sql/.down.sql files\n" +
+		"  migrate -dry-run ...        Print the SQL plan instead of executing it", runMigrate},
+	{"schema", "schema diff [-write name]   Compare entities with the live schema, optionally write a draft migration", runSchema},
+	{"routes", "routes                      Print the route table with middleware", runRoutes},
+	{"config", "config print                Print the effective config (secrets redacted)", runConfig},
+}
+
 // @Summary     Health check
 // @Description Check if the API is healthy
 // @Tags        health
@@ -45,99 +55,57 @@ import (
 // @Success     200  {object}  map[string]interface{}
 // @Router      /health [get]
 func main() {
-	// Load configuration first (needed for logging config)
-	cfg, err := config.Load()
-	if err != nil {
-		panic("Failed to load config: " + err.Error())
+	// Config file can be passed with -config or CONFIG_FILE; env vars override file values
+	configFile := flag.String("config", "", "Path to YAML/TOML config file (overrides CONFIG_FILE)")
+	flag.Usage = usage
+	flag.Parse()
+
+	// Without a subcommand the server starts, as before
+	name, args := "serve", flag.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
 	}
 
-	// Initialize response system
-	common.IsProductionMode = cfg.App.IsProduction
-
-	// Initialize error message mapping using centralized error codes
-	// Frontend can use docs/error_codes.json for reference
-	common.SetMessageMap(common.ErrorCodeDescriptions)
-
-	// Initialize logger with config
-	env := os.Getenv("ENV")
-	if env == "" {
-		env = "development"
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
+		}
 	}
-	if err := logger.Init(env, cfg.Logging.Directory, cfg.Logging.Level); err != nil {
-		panic("Failed to initialize logger: " + err.Error())
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
 	}
-	defer func() {
-		logger.Sync()
-		logger.Close()
-	}()
-
-	// Start log compression job (compress files older than X days)
-	logger.StartCompressionJob(cfg.Logging.Directory, cfg.Logging.CompressAfterDays)
-	logger.GetLogger().Info("Log compression job started",
-		zap.String("directory", cfg.Logging.Directory),
-		zap.Int("compress_after_days", cfg.Logging.CompressAfterDays),
-	)
-
-	// Start log cleanup job (delete files older than retention days)
-	logger.StartCleanupJob(cfg.Logging.Directory, cfg.Logging.RetentionDays)
-	logger.GetLogger().Info("Log cleanup job started",
-		zap.String("directory", cfg.Logging.Directory),
-		zap.Int("retention_days", cfg.Logging.RetentionDays),
-	)
-
-	// Initialize database
-	db, err := database.NewConnection(cfg.Database)
+
+	// Load configuration first (needed by every command)
+	cfg, err := config.LoadFile(*configFile)
 	if err != nil {
-		logger.GetLogger().Fatal("Failed to connect to database", zap.Error(err))
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	// Auto migrate
-	if err := database.AutoMigrate(db); err != nil {
-		logger.GetLogger().Fatal("Failed to auto migrate", zap.Error(err))
+	// Initialize logger with config before any command opens the database, so the SQL log of
+	// migrate and schema respects LOG_LEVEL too
+	if err := logger.Init(cfg.App.Env, cfg.Logging.Directory, cfg.Logging.Level); err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to initialize logger:", err)
+		os.Exit(1)
 	}
 
-	// Initialize router
-	r := router.NewRouter(db, cfg)
-
-	// Log Swagger availability
-	logger.GetLogger().Info("Swagger documentation available",
-		zap.String("url", fmt.Sprintf("http://%s:%s/swagger/index.html", cfg.Server.Host, cfg.Server.Port)))
-
-	// Start server
-	srv := server.NewServer(cfg.Server, r)
-	logger.GetLogger().Info("Server starting", zap.String("port", cfg.Server.Port))
-
-	// Start server in a goroutine
-	go func() {
-		if err := srv.Start(); err != nil {
-			logger.GetLogger().Error("Server failed to start", zap.Error(err))
-		}
-	}()
-
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.GetLogger().Info("Shutting down server...")
-
-	// The context is used to inform the server it has 30 seconds to finish
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Close database connection
-	if sqlDB, err := db.DB(); err == nil {
-		if err := sqlDB.Close(); err != nil {
-			logger.GetLogger().Error("Error closing database", zap.Error(err))
-		} else {
-			logger.GetLogger().Info("Database connection closed")
-		}
+	err = cmd.run(cfg, args)
+	logger.Sync()
+	logger.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.name, err)
+		os.Exit(1)
 	}
+}
 
-	// Shutdown server
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.GetLogger().Fatal("Server forced to shutdown", zap.Error(err))
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [-config file] <command> [arguments]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", cmd.usage)
 	}
-
-	logger.GetLogger().Info("Server exited")
+	fmt.Fprintln(os.Stderr, "\nFlags:")
+	flag.PrintDefaults()
 }
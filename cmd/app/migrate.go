@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+
+	"llm-aggregator/internal/config"
+	"llm-aggregator/internal/database"
+	"llm-aggregator/migrations"
+)
+
+// runMigrate handles migrate [-dry-run] [-allow-drift] up|down [N]|status|to <version>|create <name>
+func runMigrate(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "print the SQL that would run without executing it")
+	allowDrift := flags.Bool("allow-drift", false, "continue when applied migration files were modified")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	args = flags.Args()
+
+	if len(args) == 0 {
+		return errors.New("missing subcommand: up, down [N], status, to <version> or create <name>")
+	}
+	sub, args := args[0], args[1:]
+
+	// create only writes files, no database needed
+	if sub == "create" {
+		if len(args) != 1 {
+			return errors.New("usage: migrate create <name>")
+		}
+		upFile, downFile, err := database.CreateMigrationFiles(migrationsDir(cfg.Database), args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %s\nCreated %s\n", upFile, downFile)
+		return nil
+	}
+
+	db, err := database.NewConnection(context.Background(), cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	source, err := migrationSource(cfg.Database)
+	if err != nil {
+		return err
+	}
+	migrator, err := database.NewMigrator(db, source, database.MigratorOptions{
+		DryRun:     *dryRun,
+		AllowDrift: *allowDrift,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch sub {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			return errors.New("usage: migrate down [N]")
+		}
+		if len(args) == 1 {
+			if n, err = strconv.Atoi(args[0]); err != nil {
+				return fmt.Errorf("invalid number of migrations %q", args[0])
+			}
+		}
+		return migrator.Down(ctx, n)
+	case "to":
+		if len(args) != 1 {
+			return errors.New("usage: migrate to <version>")
+		}
+		return migrator.To(ctx, args[0])
+	case "status":
+		return printMigrationStatus(ctx, migrator)
+	default:
+		return fmt.Errorf("unknown subcommand %q", sub)
+	}
+}
+
+// migrationsDir returns the directory new migration files are written to
+func migrationsDir(cfg config.DatabaseConfig) string {
+	if cfg.MigrationsDir != "" {
+		return cfg.MigrationsDir
+	}
+	return filepath.Join("migrations", cfg.Driver)
+}
+
+// migrationSource returns the configured migrations directory, or the migrations of the
+// driver embedded in the binary
+func migrationSource(cfg config.DatabaseConfig) (fs.FS, error) {
+	if cfg.MigrationsDir != "" {
+		return os.DirFS(cfg.MigrationsDir), nil
+	}
+	return migrations.ForDriver(cfg.Driver)
+}
+
+func printMigrationStatus(ctx context.Context, migrator *database.Migrator) error {
+	states, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATUS\tAPPLIED AT\tDESCRIPTION")
+	for _, state := range states {
+		status, appliedAt := "pending", "-"
+		if state.Applied {
+			status = "applied"
+			appliedAt = state.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		switch {
+		case state.Modified:
+			status += " (modified)"
+		case state.Missing:
+			status += " (file missing)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", state.Version, status, appliedAt, state.Description)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gin-gonic/gin"
+
+	"llm-aggregator/internal/config"
+	"llm-aggregator/internal/router"
+)
+
+// routeEntry is a registered route as reported by gin
+type routeEntry struct {
+	method   string
+	path     string
+	handler  string
+	handlers int // Length of the handler chain, middleware included
+}
+
+// runRoutes prints the routes of the public and admin routers with their middleware.
+// The routers are built without a database connection; no handler is executed.
+func runRoutes(cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("routes takes no arguments, got %q", args)
+	}
+
+	title := "Public listener (" + cfg.Server.ListenAddress() + ")"
+	engine, routes := collectRoutes(func() *gin.Engine {
+		return router.NewRouter(nil, cfg, nil, nil)
+	})
+	if err := printRoutes(title, engine, routes); err != nil {
+		return err
+	}
+
+	if cfg.Server.AdminEnabled() {
+		title := fmt.Sprintf("Admin listener (%s:%s)", cfg.Server.Admin.Host, cfg.Server.Admin.Port)
+		engine, routes := collectRoutes(func() *gin.Engine {
+			return router.NewAdminRouter(nil, cfg, nil)
+		})
+		fmt.Println()
+		return printRoutes(title, engine, routes)
+	}
+	return nil
+}
+
+// collectRoutes builds an engine and records every route registered on it
+func collectRoutes(build func() *gin.Engine) (*gin.Engine, []routeEntry) {
+	var routes []routeEntry
+
+	// gin only reports routes in debug mode; keep its debug output quiet
+	previousMode, previousWriter := gin.Mode(), gin.DefaultWriter
+	gin.SetMode(gin.DebugMode)
+	gin.DefaultWriter = io.Discard
+	seen := make(map[string]bool)
+	gin.DebugPrintRouteFunc = func(method, path, handler string, handlers int) {
+		// gin may report a route more than once when it adjusts the tree
+		if key := method + " " + path; !seen[key] {
+			seen[key] = true
+			routes = append(routes, routeEntry{method: method, path: path, handler: handler, handlers: handlers})
+		}
+	}
+	defer func() {
+		gin.DebugPrintRouteFunc = nil
+		gin.DefaultWriter = previousWriter
+		gin.SetMode(previousMode)
+	}()
+
+	return build(), routes
+}
+
+func printRoutes(title string, engine *gin.Engine, routes []routeEntry) error {
+	global := make([]string, len(engine.Handlers))
+	for i, h := range engine.Handlers {
+		global[i] = shortFuncName(runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name())
+	}
+
+	fmt.Println(title)
+	fmt.Printf("Global middleware: %s\n\n", strings.Join(global, " -> "))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tHANDLER\tMIDDLEWARE")
+	for _, route := range routes {
+		middleware := "global"
+		if extra := route.handlers - len(global) - 1; extra > 0 {
+			middleware = fmt.Sprintf("global + %d route", extra)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", route.method, route.path, shortFuncName(route.handler), middleware)
+	}
+	return w.Flush()
+}
+
+// closureSuffix matches the suffix Go adds to closures and method values
+var closureSuffix = regexp.MustCompile(`(\.func\d+)+(\.\d+)*$|-fm$`)
+
+// shortFuncName turns "llm-aggregator/internal/middleware.RequestID.func1" into "middleware.RequestID"
+func shortFuncName(name string) string {
+	name = closureSuffix.ReplaceAllString(name, "")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"llm-aggregator/internal/config"
+	"llm-aggregator/internal/database"
+)
+
+// runSchema handles schema diff [-write name]
+func runSchema(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "diff" {
+		return errors.New("usage: schema diff [-write name]")
+	}
+	flags := flag.NewFlagSet("schema diff", flag.ContinueOnError)
+	write := flags.String("write", "", "write the differences as a draft migration with this name")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	db, err := database.NewConnection(context.Background(), cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	diff, err := database.DiffSchema(db)
+	if err != nil {
+		return err
+	}
+	if diff.Empty() {
+		fmt.Println("Schema matches the entities")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TABLE\tCHANGE\tNAME\tMODEL\tDATABASE")
+	for _, change := range diff.Changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", change.Table, change.Kind, dash(change.Name), dash(change.Expected), dash(change.Actual))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if *write != "" {
+		up, down := diff.DraftMigration()
+		upFile, downFile, err := database.WriteMigrationFiles(migrationsDir(cfg.Database), *write, up, down)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\nCreated %s\nCreated %s\n", upFile, downFile)
+	}
+
+	// Non-zero exit code so CI can fail on drift
+	return fmt.Errorf("%d difference(s) between the entities and the database schema", len(diff.Changes))
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
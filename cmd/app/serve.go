@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"llm-aggregator/internal/common"
+	"llm-aggregator/internal/config"
+	"llm-aggregator/internal/database"
+	"llm-aggregator/internal/lifecycle"
+	"llm-aggregator/internal/logger"
+	"llm-aggregator/internal/outbox"
+	"llm-aggregator/internal/router"
+	"llm-aggregator/internal/server"
+	"llm-aggregator/internal/store"
+)
+
+// runServe starts the HTTP server and blocks until it is shut down
+func runServe(cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("serve takes no arguments, got %q", args)
+	}
+
+	// Done on SIGINT/SIGTERM while starting; the shutdown below takes over once the server runs
+	startCtx, stopStart := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopStart()
+
+	// Initialize response system
+	common.IsProductionMode = cfg.App.IsProduction
+
+	// Initialize error message mapping using centralized error codes
+	// Frontend can use docs/error_codes.json for reference
+	common.SetMessageMap(common.ErrorCodeDescriptions)
+
+	// Retry transactions failing on deadlocks and lock wait timeouts (UnitOfWork.DoWithRetry)
+	common.SetTxRetryPolicy(common.TxRetryPolicy{
+		MaxAttempts: cfg.Database.TxRetry.MaxAttempts,
+		BaseDelay:   time.Duration(cfg.Database.TxRetry.BaseDelayMs) * time.Millisecond,
+		MaxDelay:    time.Duration(cfg.Database.TxRetry.MaxDelayMs) * time.Millisecond,
+	})
+
+	// Sign pagination cursors with a key shared by every instance
+	if cfg.App.CursorSecret.Value() != "" {
+		store.SetCursorKey([]byte(cfg.App.CursorSecret.Value()))
+	} else {
+		logger.GetLogger().Warn("CURSOR_SECRET is not set, pagination cursors are signed with a random key: " +
+			"they stop working after a restart and on other instances")
+	}
+
+	// Lifecycle manager: background jobs, readiness and ordered shutdown
+	lc := lifecycle.NewManager(seconds(cfg.Shutdown.DrainPeriodSeconds))
+
+	// Start log compression job (compress files older than X days)
+	lc.Go("log-compression", func(ctx context.Context) {
+		logger.RunCompressionJob(ctx, cfg.Logging.Directory, cfg.Logging.CompressAfterDays)
+	})
+	logger.GetLogger().Info("Log compression job started",
+		zap.String("directory", cfg.Logging.Directory),
+		zap.Int("compress_after_days", cfg.Logging.CompressAfterDays),
+	)
+
+	// Start log cleanup job (delete files older than retention days)
+	lc.Go("log-cleanup", func(ctx context.Context) {
+		logger.RunCleanupJob(ctx, cfg.Logging.Directory, cfg.Logging.RetentionDays)
+	})
+	logger.GetLogger().Info("Log cleanup job started",
+		zap.String("directory", cfg.Logging.Directory),
+		zap.Int("retention_days", cfg.Logging.RetentionDays),
+	)
+
+	// Initialize database; SIGINT/SIGTERM stop the retries while it is not reachable
+	db, err := database.NewConnection(startCtx, cfg.Database)
+	if errors.Is(err, context.Canceled) {
+		logger.GetLogger().Info("Interrupted while connecting to the database", zap.Error(err))
+		return nil
+	}
+	if err != nil {
+		logger.GetLogger().Fatal("Failed to connect to database", zap.Error(err))
+	}
+
+	// Apply pending SQL migrations; replicas starting together wait on the migration lock
+	if cfg.Database.MigrateOnStart {
+		source, err := migrationSource(cfg.Database)
+		if err != nil {
+			logger.GetLogger().Fatal("Failed to load migrations", zap.Error(err))
+		}
+		migrator, err := database.NewMigrator(db, source, database.MigratorOptions{})
+		if err != nil {
+			logger.GetLogger().Fatal("Failed to load migrations", zap.Error(err))
+		}
+		if err := migrator.Up(context.Background()); err != nil {
+			logger.GetLogger().Fatal("Failed to run migrations", zap.Error(err))
+		}
+	}
+
+	// Check read replica health and lag; unhealthy replicas get no reads
+	if len(cfg.Database.Replicas.Hosts) > 0 {
+		lc.Go("replica-monitor", func(ctx context.Context) {
+			database.MonitorReplicas(ctx, db)
+		})
+	}
+
+	// Publish connection pool stats (open, in use, idle, waits) to Prometheus
+	lc.Go("db-pool-stats", func(ctx context.Context) {
+		database.CollectPoolStats(ctx, db, database.PoolStatsInterval)
+	})
+
+	// Create the tables from the entities, for development databases the SQL migrations do not manage
+	if cfg.Database.AutoMigrate {
+		if err := database.AutoMigrate(db); err != nil {
+			logger.GetLogger().Fatal("Failed to auto migrate", zap.Error(err))
+		}
+	}
+
+	// Hard-delete users and orders soft-deleted longer than the retention
+	if cfg.Database.SoftDeleteRetentionDays > 0 {
+		lc.Go("soft-delete-purge", func(ctx context.Context) {
+			database.RunPurgeJob(ctx, db, cfg.Database.SoftDeleteRetentionDays)
+		})
+	}
+
+	// Deliver the outbox events (order.created, user.deactivated) to the configured publisher
+	publisher, err := outbox.NewPublisher(cfg.Outbox)
+	if err != nil {
+		logger.GetLogger().Fatal("Failed to create outbox publisher", zap.Error(err))
+	}
+	if publisher != nil {
+		relay := outbox.NewRelay(db, publisher, cfg.Outbox)
+		lc.Go("outbox-relay", func(ctx context.Context) {
+			relay.Run(ctx)
+			if closer, ok := publisher.(io.Closer); ok {
+				_ = closer.Close()
+			}
+		})
+		logger.GetLogger().Info("Outbox relay started", zap.String("publisher", cfg.Outbox.Publisher))
+	}
+
+	// Initialize router with hot-reloadable middleware settings
+	tunables := router.NewTunables(cfg)
+	r := router.NewRouter(db, cfg, tunables, lc.Readiness())
+
+	// Reload runtime-tunable settings (log level, rate limit, CORS origins, request timeout)
+	// on SIGHUP or when the config file changes, without a restart
+	reloader := config.NewReloader(cfg)
+	reloader.OnReload(func(newCfg *config.Config) error {
+		if err := logger.SetLevel(newCfg.Logging.Level); err != nil {
+			return err
+		}
+		tunables.Apply(newCfg)
+		return nil
+	})
+	lc.Go("config-reload", reloader.Watch)
+
+	// Operational endpoints (health, metrics, swagger, pprof) on a separate admin listener
+	var adminRouter *gin.Engine
+	swaggerURL := fmt.Sprintf("http://%s:%s/swagger/index.html", cfg.Server.Host, cfg.Server.Port)
+	if cfg.Server.TLS.Enabled {
+		swaggerURL = fmt.Sprintf("https://%s:%s/swagger/index.html", cfg.Server.Host, cfg.Server.Port)
+	}
+	if cfg.Server.AdminEnabled() {
+		adminRouter = router.NewAdminRouter(db, cfg, lc.Readiness())
+		swaggerURL = fmt.Sprintf("http://%s:%s/swagger/index.html", cfg.Server.Admin.Host, cfg.Server.Admin.Port)
+		logger.GetLogger().Info("Admin listener enabled",
+			zap.String("port", cfg.Server.Admin.Port),
+			zap.Bool("pprof", cfg.Server.Admin.Pprof),
+		)
+	}
+
+	// Log Swagger availability
+	logger.GetLogger().Info("Swagger documentation available", zap.String("url", swaggerURL))
+
+	// Start server
+	srv, err := server.NewServer(cfg.Server, r, adminRouter)
+	if err != nil {
+		logger.GetLogger().Fatal("Failed to create server", zap.Error(err))
+	}
+	logger.GetLogger().Info("Server starting", zap.String("address", cfg.Server.ListenAddress()))
+
+	// Shutdown order: HTTP (in-flight requests still need the database), background jobs,
+	// logger flush, and the database last
+	lc.OnShutdown("http server", seconds(cfg.Shutdown.HTTPTimeoutSeconds), srv.Shutdown)
+	lc.OnShutdown("background jobs", seconds(cfg.Shutdown.JobsTimeoutSeconds), lc.StopJobs)
+	lc.OnShutdown("logger flush", seconds(cfg.Shutdown.LogFlushTimeoutSeconds), func(context.Context) error {
+		return logger.Sync()
+	})
+	lc.OnShutdown("database", seconds(cfg.Shutdown.DatabaseTimeoutSeconds), func(context.Context) error {
+		return database.Close(db)
+	})
+
+	// Start server in a goroutine
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.Start()
+	}()
+	// NewServer has bound every listener, so connections queue until Start serves them
+	lc.Readiness().SetReady(true)
+
+	// Wait for interrupt signal (or a listener failure) to gracefully shutdown the server
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	var serveErr error
+	if startCtx.Err() != nil {
+		logger.GetLogger().Info("Shutting down server...", zap.String("signal", "received while starting"))
+	} else {
+		select {
+		case sig := <-quit:
+			logger.GetLogger().Info("Shutting down server...", zap.String("signal", sig.String()))
+		case err := <-serverErr:
+			logger.GetLogger().Error("Server failed", zap.Error(err))
+			serveErr = fmt.Errorf("server failed: %w", err)
+		}
+	}
+	stopStart()
+
+	// A second signal skips the graceful shutdown
+	go func() {
+		<-quit
+		logger.GetLogger().Warn("Second signal received, exiting immediately")
+		logger.Sync()
+		os.Exit(1)
+	}()
+
+	if failed := lc.Shutdown(); failed > 0 {
+		logger.GetLogger().Error("Server exited with shutdown errors", zap.Int("failed_steps", failed))
+		logger.Sync()
+		logger.Close()
+		os.Exit(1)
+	}
+
+	// A listener failure is not a clean exit, so main exits non-zero
+	if serveErr != nil {
+		return serveErr
+	}
+
+	logger.GetLogger().Info("Server exited")
+	return nil
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
@@ -0,0 +1,105 @@
+package common
+
+import (
+	"context"
+	"errors"
+
+	gosqlite "github.com/glebarez/go-sqlite"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// DBErrorKind is the driver-independent class of a database error
+type DBErrorKind string
+
+const (
+	DBErrorNone       DBErrorKind = ""
+	DBErrorNotFound   DBErrorKind = "not_found"
+	DBErrorDuplicate  DBErrorKind = "duplicate"   // Unique or primary key violation
+	DBErrorForeignKey DBErrorKind = "foreign_key" // Referenced row missing, or row still referenced
+	DBErrorConstraint DBErrorKind = "constraint"  // NOT NULL or CHECK violation
+	DBErrorDeadlock   DBErrorKind = "deadlock"    // Deadlock or serialization failure; the transaction can be retried
+	DBErrorLockWait   DBErrorKind = "lock_wait"   // Lock wait timeout; the transaction can be retried
+	DBErrorTimeout    DBErrorKind = "timeout"     // Statement or request deadline exceeded
+	DBErrorOther      DBErrorKind = "other"
+)
+
+// Retryable reports whether retrying the whole transaction may succeed
+func (k DBErrorKind) Retryable() bool {
+	return k == DBErrorDeadlock || k == DBErrorLockWait
+}
+
+// ClassifyDBError returns the kind of a database error, looking at the error codes of the
+// MySQL, PostgreSQL and SQLite drivers
+func ClassifyDBError(err error) DBErrorKind {
+	switch {
+	case err == nil:
+		return DBErrorNone
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, ErrNotFound):
+		return DBErrorNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return DBErrorDuplicate
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return DBErrorForeignKey
+	case errors.Is(err, context.DeadlineExceeded):
+		return DBErrorTimeout
+	}
+
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1062: // ER_DUP_ENTRY
+			return DBErrorDuplicate
+		case 1451, 1452: // ER_ROW_IS_REFERENCED_2, ER_NO_REFERENCED_ROW_2
+			return DBErrorForeignKey
+		case 1048, 1364, 3819: // ER_BAD_NULL_ERROR, ER_NO_DEFAULT_FOR_FIELD, ER_CHECK_CONSTRAINT_VIOLATED
+			return DBErrorConstraint
+		case 1213: // ER_LOCK_DEADLOCK
+			return DBErrorDeadlock
+		case 1205: // ER_LOCK_WAIT_TIMEOUT
+			return DBErrorLockWait
+		case 3024: // ER_QUERY_TIMEOUT (max_execution_time)
+			return DBErrorTimeout
+		}
+		return DBErrorOther
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505": // unique_violation
+			return DBErrorDuplicate
+		case "23503": // foreign_key_violation
+			return DBErrorForeignKey
+		case "23502", "23514": // not_null_violation, check_violation
+			return DBErrorConstraint
+		case "40P01", "40001": // deadlock_detected, serialization_failure
+			return DBErrorDeadlock
+		case "55P03": // lock_not_available (lock_timeout)
+			return DBErrorLockWait
+		case "57014": // query_canceled (statement_timeout)
+			return DBErrorTimeout
+		}
+		return DBErrorOther
+	}
+
+	var sqliteErr *gosqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch code := sqliteErr.Code(); code {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return DBErrorDuplicate
+		case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+			return DBErrorForeignKey
+		case sqlite3.SQLITE_CONSTRAINT_NOTNULL, sqlite3.SQLITE_CONSTRAINT_CHECK:
+			return DBErrorConstraint
+		default:
+			// busy_timeout expired while another connection held the database lock
+			if code&0xff == sqlite3.SQLITE_BUSY || code&0xff == sqlite3.SQLITE_LOCKED {
+				return DBErrorLockWait
+			}
+		}
+	}
+	return DBErrorOther
+}
@@ -110,13 +110,23 @@ func GetAllErrorCodes() ErrorCodesResponse {
 				"DUPLICATE_ENTRY": {
 					Code:       ErrorCodeDuplicateEntry,
 					Message:    ErrorCodeDescriptions[ErrorCodeDuplicateEntry],
-					HTTPStatus: 400,
+					HTTPStatus: 409,
 				},
 				"CONSTRAINT_VIOLATION": {
 					Code:       ErrorCodeConstraintViolation,
 					Message:    ErrorCodeDescriptions[ErrorCodeConstraintViolation],
 					HTTPStatus: 400,
 				},
+				"TRANSACTION_CONFLICT": {
+					Code:       ErrorCodeTransactionConflict,
+					Message:    ErrorCodeDescriptions[ErrorCodeTransactionConflict],
+					HTTPStatus: 409,
+				},
+				"VERSION_CONFLICT": {
+					Code:       ErrorCodeVersionConflict,
+					Message:    ErrorCodeDescriptions[ErrorCodeVersionConflict],
+					HTTPStatus: 409,
+				},
 			},
 		},
 	}
@@ -26,6 +26,8 @@ const (
 	ErrorCodeRecordNotFound    = "RECORD_NOT_FOUND"
 	ErrorCodeDuplicateEntry    = "DUPLICATE_ENTRY"
 	ErrorCodeConstraintViolation = "CONSTRAINT_VIOLATION"
+	ErrorCodeTransactionConflict = "TRANSACTION_CONFLICT"
+	ErrorCodeVersionConflict     = "VERSION_CONFLICT"
 )
 
 // ErrorCodeDescriptions provides default descriptions for error codes
@@ -54,5 +56,7 @@ var ErrorCodeDescriptions = map[string]string{
 	ErrorCodeRecordNotFound:    "Record not found",
 	ErrorCodeDuplicateEntry:    "Duplicate entry",
 	ErrorCodeConstraintViolation: "Constraint violation",
+	ErrorCodeTransactionConflict: "Conflicting concurrent update, please retry",
+	ErrorCodeVersionConflict:     "The resource was modified by another request",
 }
 
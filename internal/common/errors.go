@@ -9,6 +9,9 @@ var (
 	ErrNotFound = errors.New("not found")
 	ErrInvalid  = errors.New("invalid input")
 	ErrInternal = errors.New("internal error")
+
+	// ErrVersionConflict is returned by conditional updates when the row changed since it was read
+	ErrVersionConflict = errors.New("version conflict")
 )
 
 type ServiceError struct {
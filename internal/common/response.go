@@ -20,12 +20,16 @@ type ErrorInfo struct {
 	Message string `json:"message"`
 }
 
-// Pagination contains pagination metadata
+// Pagination contains pagination metadata.
+// Offset pagination (?page=) sets Page; cursor pagination (?cursor=) sets the cursors instead,
+// and Total and TotalPages on the first page only.
 type Pagination struct {
-	Page       int   `json:"page"`
-	PageSize   int   `json:"pageSize"`
-	Total      int64 `json:"total"`
-	TotalPages int   `json:"totalPages"`
+	Page       int    `json:"page,omitempty"`
+	PageSize   int    `json:"pageSize"`
+	Total      *int64 `json:"total,omitempty"`
+	TotalPages *int   `json:"totalPages,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"` // Send as ?cursor= for the next page; empty on the last page
+	PrevCursor string `json:"prevCursor,omitempty"` // Send as ?cursor= for the previous page; empty on the first page
 }
 
 // SuccessResponseDoc is used for Swagger documentation to show clean success responses
@@ -114,12 +118,32 @@ func SuccessResponseWithPagination(data interface{}, page, pageSize int, total i
 		Pagination: &Pagination{
 			Page:       page,
 			PageSize:   pageSize,
-			Total:      total,
-			TotalPages: totalPages,
+			Total:      &total,
+			TotalPages: &totalPages,
 		},
 	}
 }
 
+// SuccessResponseWithCursor creates a success response with cursor pagination; total is nil
+// when the rows were not counted
+func SuccessResponseWithCursor(data interface{}, pageSize int, total *int64, nextCursor, prevCursor string) *AppResponse {
+	pagination := &Pagination{
+		PageSize:   pageSize,
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
+	}
+	if total != nil {
+		totalPages := CalculateTotalPages(*total, pageSize)
+		pagination.Total = total
+		pagination.TotalPages = &totalPages
+	}
+	return &AppResponse{
+		IsSuccess:  true,
+		Data:       data,
+		Pagination: pagination,
+	}
+}
+
 // FailResponse creates a failure response with error code
 // The message is automatically retrieved from messageMap
 func FailResponse(code string) *AppResponse {
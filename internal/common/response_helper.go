@@ -22,6 +22,12 @@ func RespondSuccessWithPagination(c *gin.Context, data interface{}, page, pageSi
 	c.JSON(http.StatusOK, SuccessResponseWithPagination(data, page, pageSize, total))
 }
 
+// RespondSuccessWithCursor sends a success response with cursor pagination; total is nil
+// when the rows were not counted
+func RespondSuccessWithCursor(c *gin.Context, data interface{}, pageSize int, total *int64, nextCursor, prevCursor string) {
+	c.JSON(http.StatusOK, SuccessResponseWithCursor(data, pageSize, total, nextCursor, prevCursor))
+}
+
 // RespondFail sends a failure response with error code
 // The HTTP status code is determined by the error code
 func RespondFail(c *gin.Context, code string) {
@@ -87,9 +93,10 @@ func mapErrorCodeToHTTPStatus(code string) int {
 	case ErrorCodeNotFound, ErrorCodeUserNotFound, ErrorCodeRecordNotFound:
 		return http.StatusNotFound
 	case ErrorCodeBadRequest, ErrorCodeInvalid, ErrorCodeValidationError,
-		ErrorCodeEmailExists, ErrorCodeUserAlreadyExists, ErrorCodeDuplicateEntry,
-		ErrorCodeConstraintViolation:
+		ErrorCodeEmailExists, ErrorCodeUserAlreadyExists, ErrorCodeConstraintViolation:
 		return http.StatusBadRequest
+	case ErrorCodeDuplicateEntry, ErrorCodeTransactionConflict, ErrorCodeVersionConflict:
+		return http.StatusConflict
 	case ErrorCodeUnauthorized, ErrorCodeInvalidCredentials:
 		return http.StatusUnauthorized
 	case ErrorCodeForbidden, ErrorCodeUserInactive:
@@ -16,24 +16,61 @@ import (
 //
 // Parameters:
 //   - err: The error from repository
-//   - notFoundMessage: Message to return if error is ErrNotFound
-//   - notFoundCode: Error code to return if error is ErrNotFound
+//   - notFoundMessage: Message to return if error is ErrNotFound (or gorm.ErrRecordNotFound)
+//   - notFoundCode: Error code to return if error is ErrNotFound (or gorm.ErrRecordNotFound)
 //   - internalErrorMessage: Message to return for other errors
 //
+// Database errors are classified with ClassifyDBError: unique violations return DUPLICATE_ENTRY (409),
+// foreign key, NOT NULL and CHECK violations CONSTRAINT_VIOLATION (400), deadlocks and lock wait
+// timeouts TRANSACTION_CONFLICT (409, safe to retry) and timeouts REQUEST_TIMEOUT.
+// ErrVersionConflict (a failed conditional update or If-Match) returns VERSION_CONFLICT (409).
+//
 // Returns:
 //   - *ServiceError: A properly formatted ServiceError
 func HandleRepositoryError(err error, notFoundMessage, notFoundCode, internalErrorMessage string) *ServiceError {
 	if err == nil {
 		return nil
 	}
+	if errors.Is(err, ErrVersionConflict) {
+		return NewServiceError(err, versionConflictMessage, ErrorCodeVersionConflict)
+	}
 
-	if errors.Is(err, ErrNotFound) {
+	switch kind := ClassifyDBError(err); {
+	case kind == DBErrorNotFound:
 		return NewServiceError(err, notFoundMessage, notFoundCode)
+	case kind == DBErrorDuplicate:
+		return NewServiceError(err, "A record with the same unique value already exists", ErrorCodeDuplicateEntry)
+	case kind == DBErrorForeignKey || kind == DBErrorConstraint:
+		return NewServiceError(err, "The change violates a database constraint", ErrorCodeConstraintViolation)
+	case kind.Retryable():
+		return NewServiceError(err, "Conflicting concurrent update, please retry", ErrorCodeTransactionConflict)
+	case kind == DBErrorTimeout:
+		return NewServiceError(err, "Database operation timed out", ErrorCodeRequestTimeout)
 	}
 
 	return NewServiceError(err, internalErrorMessage, ErrorCodeInternalError)
 }
 
+// HandleTransactionError handles the error of a transaction (UnitOfWork.Do, DoWithRetry).
+// Service errors returned by the transaction function are returned as is, other errors (e.g. a
+// serialization failure on commit) are classified like in HandleRepositoryError.
+//
+// Usage:
+//   err := s.uow.Do(ctx, func(ctx context.Context) error { ... })
+//   if err != nil {
+//       return HandleTransactionError(err, "Failed to create order")
+//   }
+func HandleTransactionError(err error, internalErrorMessage string) error {
+	if err == nil {
+		return nil
+	}
+	var svcErr *ServiceError
+	if errors.As(err, &svcErr) {
+		return svcErr
+	}
+	return HandleRepositoryError(err, "", "", internalErrorMessage)
+}
+
 // HandleRepositoryErrorWithReturn handles repository errors for methods that return (T, error).
 // This is similar to HandleRepositoryError but returns (nil, error) for consistency.
 //
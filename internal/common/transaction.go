@@ -33,8 +33,10 @@ func NewTransactionManager(db *gorm.DB) *TransactionManager {
 //       }
 //       return nil
 //   })
+//
+// When ctx carries a transaction (see UnitOfWork), fn runs in a savepoint of it.
 func (tm *TransactionManager) Execute(ctx context.Context, fn func(tx *gorm.DB) error) error {
-	return tm.db.WithContext(ctx).Transaction(fn)
+	return DBFromContext(ctx, tm.db).Transaction(fn)
 }
 
 // Transaction is a convenience function that executes a function within a transaction.
@@ -56,8 +58,10 @@ func Transaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
 //       // Perform operations
 //       return nil
 //   })
+//
+// When ctx carries a transaction (see UnitOfWork), fn runs in a savepoint of it.
 func TransactionWithContext(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
-	return db.WithContext(ctx).Transaction(fn)
+	return DBFromContext(ctx, db).Transaction(fn)
 }
 
 // IsTransactionError checks if an error is a transaction-related error.
@@ -0,0 +1,179 @@
+package common
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"llm-aggregator/internal/logger"
+	"llm-aggregator/internal/metrics"
+)
+
+// TxRetryPolicy controls how DoWithRetry retries transactions failing with a retryable error
+// (deadlock, serialization failure, lock wait timeout; see DBErrorKind.Retryable)
+type TxRetryPolicy struct {
+	MaxAttempts int           // Attempts including the first; 1 disables retries
+	BaseDelay   time.Duration // Backoff before the first retry, doubled for each further retry
+	MaxDelay    time.Duration // Upper bound of the backoff
+}
+
+// DefaultTxRetryPolicy is used until SetTxRetryPolicy is called
+var DefaultTxRetryPolicy = TxRetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   20 * time.Millisecond,
+	MaxDelay:    500 * time.Millisecond,
+}
+
+var (
+	txRetryPolicy      = DefaultTxRetryPolicy
+	txRetryPolicyMutex sync.RWMutex
+)
+
+// SetTxRetryPolicy sets the retry policy of DoWithRetry and ExecuteWithRetry.
+// This should be called during application startup.
+func SetTxRetryPolicy(policy TxRetryPolicy) {
+	txRetryPolicyMutex.Lock()
+	defer txRetryPolicyMutex.Unlock()
+	txRetryPolicy = policy
+}
+
+func currentTxRetryPolicy() TxRetryPolicy {
+	txRetryPolicyMutex.RLock()
+	defer txRetryPolicyMutex.RUnlock()
+	return txRetryPolicy
+}
+
+// backoff returns the wait before retry number attempt (1 for the first retry): a random
+// duration up to BaseDelay doubled attempt-1 times, capped at MaxDelay ("full jitter", so
+// transactions that deadlocked on each other do not retry in lockstep)
+func (p TxRetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.MaxDelay
+	if attempt-1 < 31 {
+		if d := p.BaseDelay << (attempt - 1); d > 0 && d < delay {
+			delay = d
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay + 1)
+}
+
+type txAttemptKey struct{}
+
+// txAttempt is the state of one DoWithRetry attempt, carried by the context of the callback
+type txAttempt struct {
+	number        int
+	notIdempotent atomic.Bool
+}
+
+// MarkNotIdempotent records that the current DoWithRetry attempt did something a rollback does not
+// undo (an HTTP call, a published message, a sent email), so a failed attempt is returned instead
+// of retried. Outside DoWithRetry it does nothing.
+func MarkNotIdempotent(ctx context.Context) {
+	if attempt, ok := ctx.Value(txAttemptKey{}).(*txAttempt); ok {
+		attempt.notIdempotent.Store(true)
+	}
+}
+
+// TxAttempt returns the DoWithRetry attempt ctx runs in (1 for the first), or 0 outside DoWithRetry
+func TxAttempt(ctx context.Context) int {
+	if attempt, ok := ctx.Value(txAttemptKey{}).(*txAttempt); ok {
+		return attempt.number
+	}
+	return 0
+}
+
+// DoWithRetry runs fn in a transaction like Do, and runs it again in a new transaction when it
+// fails with a deadlock, serialization failure or lock wait timeout. operation names the
+// transaction in logs and metrics (e.g. "order.create").
+//
+// fn must be safe to run more than once: every attempt starts from scratch, so fn has to read
+// what it needs inside the transaction and keep side effects out of it. Call MarkNotIdempotent
+// before a side effect that cannot be repeated; the attempt is then not retried.
+//
+// Called with a context that already carries a transaction, fn runs once in a savepoint: the
+// database aborted the whole transaction, so the outermost DoWithRetry retries it.
+//
+// Usage:
+//   err := uow.DoWithRetry(ctx, "order.create", func(ctx context.Context) error {
+//       return s.repo.Create(ctx, order)
+//   })
+func (u *UnitOfWork) DoWithRetry(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	if InTransaction(ctx) {
+		return u.Do(ctx, fn)
+	}
+
+	policy := currentTxRetryPolicy()
+	for number := 1; ; number++ {
+		attempt := &txAttempt{number: number}
+		err := u.Do(context.WithValue(ctx, txAttemptKey{}, attempt), fn)
+		kind := ClassifyDBError(err)
+		if !kind.Retryable() {
+			return err
+		}
+
+		var reason string
+		switch {
+		case attempt.notIdempotent.Load():
+			reason = "not_idempotent"
+		case number >= policy.MaxAttempts:
+			reason = "exhausted"
+		default:
+			delay := policy.backoff(number)
+			logger.WithContext(ctx).Warn("Retrying transaction",
+				zap.String("operation", operation),
+				zap.Int("attempt", number),
+				zap.String("error_type", string(kind)),
+				zap.Duration("backoff", delay),
+				zap.Error(err),
+			)
+			if waitErr := sleepContext(ctx, delay); waitErr != nil {
+				reason = "canceled"
+				break
+			}
+			metrics.DatabaseTransactionRetriesTotal.WithLabelValues(operation, string(kind)).Inc()
+			continue
+		}
+
+		metrics.DatabaseTransactionFailuresTotal.WithLabelValues(operation, string(kind), reason).Inc()
+		logger.WithContext(ctx).Error("Transaction failed",
+			zap.String("operation", operation),
+			zap.Int("attempts", number),
+			zap.String("error_type", string(kind)),
+			zap.String("reason", reason),
+			zap.Error(err),
+		)
+		return err
+	}
+}
+
+// ExecuteWithRetry executes fn within a transaction, retried on deadlocks and lock wait timeouts.
+// See UnitOfWork.DoWithRetry for the rules fn has to follow.
+//
+// Usage:
+//   err := txManager.ExecuteWithRetry(ctx, "order.update", func(tx *gorm.DB) error {
+//       return repo.WithTx(tx).Update(ctx, entity)
+//   })
+func (tm *TransactionManager) ExecuteWithRetry(ctx context.Context, operation string, fn func(tx *gorm.DB) error) error {
+	return NewUnitOfWork(tm.db).DoWithRetry(ctx, operation, func(ctx context.Context) error {
+		return fn(DBFromContext(ctx, tm.db))
+	})
+}
+
+// sleepContext waits for d, or returns the error of ctx when it is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,70 @@
+package common
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type txContextKey struct{}
+
+// ContextWithTx returns a context carrying the transaction tx
+func ContextWithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext returns the transaction carried by ctx, if any
+func TxFromContext(ctx context.Context) (*gorm.DB, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(*gorm.DB)
+	return tx, ok
+}
+
+// DBFromContext returns the transaction carried by ctx, or db outside a unit of work.
+// Repositories use it for every query, so they join the caller's transaction without WithTx.
+//
+// Usage:
+//   func (r *orderRepository) Create(ctx context.Context, order *entity.Order) error {
+//       return common.DBFromContext(ctx, r.db).Create(order).Error
+//   }
+func DBFromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// UnitOfWork runs functions in a transaction carried by their context.
+// Everything called with that context (repositories, other modules through the
+// ModuleContainer) runs in the same transaction.
+type UnitOfWork struct {
+	db *gorm.DB
+}
+
+// NewUnitOfWork creates a unit of work on db
+func NewUnitOfWork(db *gorm.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do runs fn in a transaction, committed when fn returns nil and rolled back otherwise.
+// Called with a context that already carries a transaction, fn runs in a savepoint of it:
+// an error rolls back the changes of fn only, and the outer transaction decides the rest.
+//
+// Usage:
+//   err := uow.Do(ctx, func(ctx context.Context) error {
+//       if _, err := s.container.UserGetter.GetUserByID(ctx, userID); err != nil {
+//           return err // the user lookup runs in the transaction too
+//       }
+//       return s.repo.Create(ctx, order)
+//   })
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	// gorm opens a savepoint when Transaction is called on a transaction
+	return DBFromContext(ctx, u.db).Transaction(func(tx *gorm.DB) error {
+		return fn(ContextWithTx(ctx, tx))
+	})
+}
+
+// InTransaction reports whether ctx carries a transaction
+func InTransaction(ctx context.Context) bool {
+	_, ok := TxFromContext(ctx)
+	return ok
+}
@@ -0,0 +1,74 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Optimistic concurrency: entities carry a version that every update increments. Updates only
+// apply when the version is still the one that was read, GET responses expose it as an ETag and
+// clients send it back in If-Match, so concurrent edits fail with VERSION_CONFLICT (409) instead
+// of overwriting each other.
+
+const versionConflictMessage = "The resource was modified by another request"
+
+// CheckVersion returns a VERSION_CONFLICT service error when expected (from If-Match, 0 for none)
+// is not the current version
+func CheckVersion(current, expected int64) error {
+	if expected != 0 && current != expected {
+		return NewServiceError(ErrVersionConflict, versionConflictMessage, ErrorCodeVersionConflict)
+	}
+	return nil
+}
+
+// VersionMissError explains a conditional update or delete of the row id that matched no row:
+// ErrNotFound when the row does not exist, ErrVersionConflict when its version changed.
+//
+// Usage:
+//   if result.RowsAffected == 0 {
+//       return common.VersionMissError(db, &entity.Order{}, order.ID)
+//   }
+func VersionMissError(db *gorm.DB, model interface{}, id string) error {
+	var count int64
+	if err := db.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrVersionConflict
+}
+
+// ETag returns the entity tag of a resource version
+func ETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// SetETag sets the ETag header of the response to the resource version
+func SetETag(c *gin.Context, version int64) {
+	c.Header("ETag", ETag(version))
+}
+
+// IfMatchVersion returns the version sent in the If-Match header, or 0 when the header is absent or
+// "*" (any version). Weak tags (W/"3") are rejected like malformed ones: If-Match uses the strong
+// comparison (RFC 9110), which a weak tag never passes.
+func IfMatchVersion(c *gin.Context) (int64, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	if strings.HasPrefix(value, "W/") {
+		return 0, fmt.Errorf("invalid If-Match header %q: weak ETags never match, send the ETag returned by GET", value)
+	}
+	tag, err := strconv.Unquote(value)
+	if err == nil {
+		if version, err := strconv.ParseInt(tag, 10, 64); err == nil && version > 0 {
+			return version, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid If-Match header %q: expected a single ETag returned by GET", value)
+}
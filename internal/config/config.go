@@ -2,146 +2,599 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
+	"go.yaml.in/yaml/v3"
 )
 
+// ConfigFileEnv is the environment variable holding the path to an optional config file
+const ConfigFileEnv = "CONFIG_FILE"
+
+// Fields tagged `reloadable:"true"` can be changed at runtime (see Reloader).
+// Sensitive values use the Secret type and are redacted whenever printed or logged.
 type Config struct {
-	Server       ServerConfig
-	Database     DatabaseConfig
-	Logging      LoggingConfig
-	ServerLimits ServerLimitsConfig
-	App          AppConfig
+	Server       ServerConfig       `yaml:"server" toml:"server"`
+	Database     DatabaseConfig     `yaml:"database" toml:"database"`
+	Logging      LoggingConfig      `yaml:"logging" toml:"logging"`
+	ServerLimits ServerLimitsConfig `yaml:"server_limits" toml:"server_limits"`
+	App          AppConfig          `yaml:"app" toml:"app"`
+	Reload       ReloadConfig       `yaml:"reload" toml:"reload"`
+	Secrets      SecretsConfig      `yaml:"secrets" toml:"secrets"`
+	Shutdown     ShutdownConfig     `yaml:"shutdown" toml:"shutdown"`
+	Outbox       OutboxConfig       `yaml:"outbox" toml:"outbox"`
+
+	file string // Config file this config was loaded from (empty if none)
 }
 
 type ServerConfig struct {
-	Port       string
-	Host       string
-	CORSOrigins string // Comma-separated list of allowed CORS origins
+	Port        string `yaml:"port" toml:"port"`
+	Host        string `yaml:"host" toml:"host"`
+	Network     string `yaml:"network" toml:"network"`                             // tcp (host/port), unix (socket_path) or systemd (socket activation)
+	SocketPath  string `yaml:"socket_path" toml:"socket_path"`                     // Unix socket path, removed on shutdown
+	SocketMode  string `yaml:"socket_mode" toml:"socket_mode"`                     // Octal permissions of the Unix socket, e.g. "0660"
+	CORSOrigins string `yaml:"cors_origins" toml:"cors_origins" reloadable:"true"` // Comma-separated list of allowed CORS origins
+
+	// http.Server settings; timeouts of 0 disable the corresponding timeout
+	ReadTimeoutSeconds       int  `yaml:"read_timeout_seconds" toml:"read_timeout_seconds"`               // Max time to read the full request including body
+	ReadHeaderTimeoutSeconds int  `yaml:"read_header_timeout_seconds" toml:"read_header_timeout_seconds"` // Max time to read request headers
+	WriteTimeoutSeconds      int  `yaml:"write_timeout_seconds" toml:"write_timeout_seconds"`             // Must exceed server_limits.request_timeout_seconds
+	IdleTimeoutSeconds       int  `yaml:"idle_timeout_seconds" toml:"idle_timeout_seconds"`               // Max time to wait for the next request on a keep-alive connection
+	MaxHeaderBytes           int  `yaml:"max_header_bytes" toml:"max_header_bytes"`                       // Max size of request headers
+	KeepAlive                bool `yaml:"keep_alive" toml:"keep_alive"`                                   // Enable HTTP keep-alive
+
+	TLS   TLSConfig   `yaml:"tls" toml:"tls"`
+	Admin AdminConfig `yaml:"admin" toml:"admin"`
+}
+
+// AdminConfig configures the optional operational listener (health, metrics, swagger, pprof).
+// When Port is empty the operational endpoints stay on the public listener.
+type AdminConfig struct {
+	Port  string `yaml:"port" toml:"port"`   // Empty disables the admin listener
+	Host  string `yaml:"host" toml:"host"`   // Defaults to loopback so the port is not exposed
+	Pprof bool   `yaml:"pprof" toml:"pprof"` // Expose net/http/pprof under /debug/pprof
+}
+
+// ListenAddress describes where the public listener accepts connections, for logging
+func (c ServerConfig) ListenAddress() string {
+	switch c.Network {
+	case "unix":
+		return "unix:" + c.SocketPath
+	case "systemd":
+		return "systemd socket activation"
+	default:
+		return fmt.Sprintf("%s:%s", c.Host, c.Port)
+	}
+}
+
+// SocketFileMode returns SocketMode parsed as octal file permissions
+func (c ServerConfig) SocketFileMode() (os.FileMode, error) {
+	mode, err := strconv.ParseUint(c.SocketMode, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid socket mode %q: must be octal permissions such as 0660", c.SocketMode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// AdminEnabled reports whether operational endpoints are served on a separate admin listener
+func (c ServerConfig) AdminEnabled() bool {
+	return c.Admin.Port != ""
 }
 
+// TLSConfig configures TLS termination in the app and optional client certificate verification (mTLS).
+// Certificate, key and CA files are re-read automatically when they change on disk.
+type TLSConfig struct {
+	Enabled               bool     `yaml:"enabled" toml:"enabled"`
+	CertFile              string   `yaml:"cert_file" toml:"cert_file"`                             // PEM certificate (chain)
+	KeyFile               string   `yaml:"key_file" toml:"key_file"`                               // PEM private key
+	MinVersion            string   `yaml:"min_version" toml:"min_version"`                         // "1.2" or "1.3"
+	CipherSuites          []string `yaml:"cipher_suites" toml:"cipher_suites"`                     // IANA names, TLS 1.2 only; empty uses Go defaults
+	ClientCAFile          string   `yaml:"client_ca_file" toml:"client_ca_file"`                   // PEM CA bundle used to verify client certificates
+	ClientAuth            string   `yaml:"client_auth" toml:"client_auth"`                         // none, request, require, verify_if_given, require_and_verify
+	ReloadIntervalSeconds int      `yaml:"reload_interval_seconds" toml:"reload_interval_seconds"` // Poll interval for certificate changes, 0 disables
+}
+
+// Database drivers
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	Charset  string
+	Driver   string `yaml:"driver" toml:"driver"` // mysql, postgres or sqlite
+	Host     string `yaml:"host" toml:"host"`
+	Port     string `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password Secret `yaml:"password" toml:"password"` // Prefer DB_PASSWORD_FILE or the encrypted secrets file
+	DBName   string `yaml:"name" toml:"name"`         // Database name; for sqlite the database file path or ":memory:"
+	Charset  string `yaml:"charset" toml:"charset"`   // mysql only
+	SSLMode  string `yaml:"sslmode" toml:"sslmode"`   // postgres only: disable, require, verify-ca or verify-full
+
+	MigrationsDir  string `yaml:"migrations_dir" toml:"migrations_dir"`     // Directory holding <version>_<name>.up.sql/.down.sql files; empty uses the migrations embedded in the binary
+	MigrateOnStart bool   `yaml:"migrate_on_start" toml:"migrate_on_start"` // Apply pending migrations when serve starts
+	AutoMigrate    bool   `yaml:"auto_migrate" toml:"auto_migrate"`         // Create/update tables from the entities when serve starts (development only)
+
+	// Connection pool; 0 means unlimited for max_open_conns and the lifetimes
+	MaxOpenConns           int `yaml:"max_open_conns" toml:"max_open_conns"`                         // Max open connections (sqlite always uses 1)
+	MaxIdleConns           int `yaml:"max_idle_conns" toml:"max_idle_conns"`                         // Max idle connections kept in the pool
+	ConnMaxLifetimeSeconds int `yaml:"conn_max_lifetime_seconds" toml:"conn_max_lifetime_seconds"`   // Connections are closed after this long, e.g. to follow DNS changes
+	ConnMaxIdleTimeSeconds int `yaml:"conn_max_idle_time_seconds" toml:"conn_max_idle_time_seconds"` // Idle connections are closed after this long
+	ConnectTimeoutSeconds  int `yaml:"connect_timeout_seconds" toml:"connect_timeout_seconds"`       // Retry the initial connection with backoff for this long; 0 tries once
+
+	SlowQueryMs  int  `yaml:"slow_query_ms" toml:"slow_query_ms"` // Queries slower than this are logged at warn; 0 disables
+	RedactParams bool `yaml:"redact_params" toml:"redact_params"` // Log SQL with ? placeholders instead of the bound values
+
+	SoftDeleteRetentionDays int `yaml:"soft_delete_retention_days" toml:"soft_delete_retention_days"` // Soft-deleted users and orders are purged after this many days; 0 keeps them forever
+
+	TxRetry  TxRetryConfig `yaml:"tx_retry" toml:"tx_retry"`
+	Replicas ReplicaConfig `yaml:"replicas" toml:"replicas"`
+}
+
+// TxRetryConfig configures the retry of transactions failing on a deadlock, serialization
+// failure or lock wait timeout
+type TxRetryConfig struct {
+	MaxAttempts int `yaml:"max_attempts" toml:"max_attempts"`   // Attempts including the first; 1 disables retries
+	BaseDelayMs int `yaml:"base_delay_ms" toml:"base_delay_ms"` // Backoff before the first retry, doubled for each further retry (with full jitter)
+	MaxDelayMs  int `yaml:"max_delay_ms" toml:"max_delay_ms"`   // Upper bound of the backoff
+}
+
+// ReplicaConfig configures read replicas. Reads go to a healthy replica, writes and transactions
+// to the primary. Replicas use the credentials and database name of the primary.
+type ReplicaConfig struct {
+	Hosts                []string `yaml:"hosts" toml:"hosts"`                                   // host or host:port of each replica (port defaults to database.port)
+	MaxLagSeconds        int      `yaml:"max_lag_seconds" toml:"max_lag_seconds"`               // Replicas lagging more are skipped until they catch up
+	StickySeconds        int      `yaml:"sticky_seconds" toml:"sticky_seconds"`                 // Reads go to the primary for this long after a write in the same request
+	CheckIntervalSeconds int      `yaml:"check_interval_seconds" toml:"check_interval_seconds"` // Interval of the replica health and lag checks
+}
+
+// ForReplica returns the connection settings of a replica given as host or host:port
+func (d DatabaseConfig) ForReplica(address string) DatabaseConfig {
+	replica := d
+	replica.Host, replica.Port = address, d.Port
+	if host, port, err := net.SplitHostPort(address); err == nil {
+		replica.Host, replica.Port = host, port
+	}
+	replica.Replicas = ReplicaConfig{}
+	return replica
 }
 
 type LoggingConfig struct {
-	Directory         string
-	RetentionDays     int
-	CompressAfterDays int
-	Level             string
+	Directory         string `yaml:"directory" toml:"directory"`
+	RetentionDays     int    `yaml:"retention_days" toml:"retention_days"`
+	CompressAfterDays int    `yaml:"compress_after_days" toml:"compress_after_days"`
+	Level             string `yaml:"level" toml:"level" reloadable:"true"`
 }
 
 type ServerLimitsConfig struct {
-	RequestTimeoutSeconds int     // Request timeout in seconds
-	RateLimitRPS          float64 // Rate limit requests per second
-	RateLimitBurst        int     // Rate limit burst size
-	MaxRequestSizeMB      int     // Max request size in MB
+	RequestTimeoutSeconds int     `yaml:"request_timeout_seconds" toml:"request_timeout_seconds" reloadable:"true"` // Request timeout in seconds
+	RateLimitRPS          float64 `yaml:"rate_limit_rps" toml:"rate_limit_rps" reloadable:"true"`                   // Rate limit requests per second
+	RateLimitBurst        int     `yaml:"rate_limit_burst" toml:"rate_limit_burst" reloadable:"true"`               // Rate limit burst size
+	MaxRequestSizeMB      int     `yaml:"max_request_size_mb" toml:"max_request_size_mb"`                           // Max request size in MB
 }
 
 type AppConfig struct {
-	IsProduction bool
+	Env          string `yaml:"env" toml:"env"`                     // development, staging or production
+	IsProduction bool   `yaml:"-" toml:"-"`                         // Derived from Env
+	CursorSecret Secret `yaml:"cursor_secret" toml:"cursor_secret"` // Signs pagination cursors; random per process when empty
+}
+
+type ReloadConfig struct {
+	WatchIntervalSeconds int `yaml:"watch_interval_seconds" toml:"watch_interval_seconds"` // Config file poll interval, 0 disables file watching (SIGHUP still works)
+}
+
+type SecretsConfig struct {
+	File    string `yaml:"file" toml:"file"`         // Optional AES-256-GCM encrypted secrets file (.env syntax once decrypted)
+	KeyFile string `yaml:"key_file" toml:"key_file"` // File holding the 32 byte key, hex or base64 encoded
+}
+
+// ShutdownConfig controls graceful shutdown. Each step has its own timeout.
+type ShutdownConfig struct {
+	DrainPeriodSeconds     int `yaml:"drain_period_seconds" toml:"drain_period_seconds"`           // Time between reporting not-ready and closing listeners
+	HTTPTimeoutSeconds     int `yaml:"http_timeout_seconds" toml:"http_timeout_seconds"`           // Max wait for in-flight requests
+	JobsTimeoutSeconds     int `yaml:"jobs_timeout_seconds" toml:"jobs_timeout_seconds"`           // Max wait for background jobs to stop
+	LogFlushTimeoutSeconds int `yaml:"log_flush_timeout_seconds" toml:"log_flush_timeout_seconds"` // Max wait for log files to flush
+	DatabaseTimeoutSeconds int `yaml:"database_timeout_seconds" toml:"database_timeout_seconds"`   // Max wait for the database pool to close
+}
+
+// OutboxConfig controls the relay delivering the domain events of the outbox table
+type OutboxConfig struct {
+	Publisher             string `yaml:"publisher" toml:"publisher"`                             // none, stdout, file or webhook; none leaves the events in the table
+	FilePath              string `yaml:"file_path" toml:"file_path"`                             // File the file publisher appends events to, one JSON object per line
+	WebhookURL            string `yaml:"webhook_url" toml:"webhook_url"`                         // URL the webhook publisher POSTs each event to
+	WebhookSecret         Secret `yaml:"webhook_secret" toml:"webhook_secret"`                   // Signs webhook bodies (HMAC-SHA256); prefer OUTBOX_WEBHOOK_SECRET_FILE
+	WebhookTimeoutSeconds int    `yaml:"webhook_timeout_seconds" toml:"webhook_timeout_seconds"` // Timeout of one webhook call
+	PollIntervalMs        int    `yaml:"poll_interval_ms" toml:"poll_interval_ms"`               // How often the relay looks for pending events
+	BatchSize             int    `yaml:"batch_size" toml:"batch_size"`                           // Events claimed per poll
+	MaxAttempts           int    `yaml:"max_attempts" toml:"max_attempts"`                       // Delivery attempts before an event is marked failed
+	RetryMaxDelaySeconds  int    `yaml:"retry_max_delay_seconds" toml:"retry_max_delay_seconds"` // Upper bound of the backoff between attempts
+	RetentionDays         int    `yaml:"retention_days" toml:"retention_days"`                   // Delete delivered events after this many days; 0 keeps them
 }
 
+// Load builds the configuration from defaults, the optional file referenced by
+// CONFIG_FILE and environment variables (highest precedence).
 func Load() (*Config, error) {
+	return LoadFile("")
+}
+
+// LoadFile builds the configuration using the given config file path.
+// An empty path falls back to the CONFIG_FILE environment variable; if that is
+// empty too, only defaults and environment variables are used.
+//
+// Precedence (lowest to highest): defaults, config file, environment variables.
+// All invalid values are reported together as ValidationErrors.
+func LoadFile(path string) (*Config, error) {
 	// Load .env file if exists
 	_ = godotenv.Load()
 
-	retentionDays := 30
-	if days := getEnv("LOG_RETENTION_DAYS", "30"); days != "" {
-		if parsed, err := strconv.Atoi(days); err == nil {
-			retentionDays = parsed
-		}
-	}
-
-	compressAfterDays := 7
-	if days := getEnv("LOG_COMPRESS_AFTER_DAYS", "7"); days != "" {
-		if parsed, err := strconv.Atoi(days); err == nil {
-			compressAfterDays = parsed
-		}
+	if path == "" {
+		path = os.Getenv(ConfigFileEnv)
 	}
 
-	// Server limits config
-	requestTimeout := 30
-	if timeout := getEnv("REQUEST_TIMEOUT_SECONDS", "30"); timeout != "" {
-		if parsed, err := strconv.Atoi(timeout); err == nil {
-			requestTimeout = parsed
-		}
-	}
+	cfg := defaults()
+	cfg.file = path
 
-	rateLimitRPS := 100.0
-	if rps := getEnv("RATE_LIMIT_RPS", "100"); rps != "" {
-		if parsed, err := strconv.ParseFloat(rps, 64); err == nil {
-			rateLimitRPS = parsed
+	if path != "" {
+		if err := loadFromFile(path, cfg); err != nil {
+			return nil, err
 		}
 	}
 
-	rateLimitBurst := 200
-	if burst := getEnv("RATE_LIMIT_BURST", "200"); burst != "" {
-		if parsed, err := strconv.Atoi(burst); err == nil {
-			rateLimitBurst = parsed
-		}
-	}
+	var errs ValidationErrors
+	applyEnv(cfg, &errs)
+	resolveSecrets(cfg, &errs)
+	cfg.normalize()
+	errs = append(errs, cfg.Validate()...)
 
-	maxRequestSizeMB := 10
-	if size := getEnv("MAX_REQUEST_SIZE_MB", "10"); size != "" {
-		if parsed, err := strconv.Atoi(size); err == nil {
-			maxRequestSizeMB = parsed
-		}
+	if len(errs) > 0 {
+		return nil, errs
 	}
 
-	// Determine if production mode
-	env := getEnv("ENV", "development")
-	isProduction := env == "production" || env == "prod"
+	return cfg, nil
+}
 
-	cfg := &Config{
+// defaults returns the configuration used when neither file nor env provide a value
+func defaults() *Config {
+	return &Config{
 		Server: ServerConfig{
-			Port:        getEnv("SERVER_PORT", "8085"),
-			Host:        getEnv("SERVER_HOST", "0.0.0.0"),
-			CORSOrigins: getEnv("CORS_ORIGINS", ""),
+			Port:                     "8085",
+			Network:                  "tcp",
+			SocketMode:               "0660",
+			Host:                     "0.0.0.0",
+			ReadTimeoutSeconds:       15,
+			ReadHeaderTimeoutSeconds: 5,
+			WriteTimeoutSeconds:      35,
+			IdleTimeoutSeconds:       60,
+			MaxHeaderBytes:           1 << 20, // 1 MB
+			KeepAlive:                true,
+			TLS: TLSConfig{
+				MinVersion:            "1.2",
+				ReloadIntervalSeconds: 30,
+			},
+			Admin: AdminConfig{
+				Host:  "127.0.0.1",
+				Pprof: true,
+			},
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "3306"),
-			User:     getEnv("DB_USER", "root"),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", "clean_architecture"),
-			Charset:  getEnv("DB_CHARSET", "utf8mb4"),
+			Driver:  DriverMySQL,
+			Host:    "localhost",
+			User:    "root",
+			DBName:  "clean_architecture",
+			Charset: "utf8mb4",
+			SSLMode: "disable",
+
+			MaxOpenConns:           100,
+			MaxIdleConns:           10,
+			ConnMaxLifetimeSeconds: 3600,
+			ConnMaxIdleTimeSeconds: 300,
+			ConnectTimeoutSeconds:  60,
+
+			SlowQueryMs:             200,
+			SoftDeleteRetentionDays: 30,
+			TxRetry: TxRetryConfig{
+				MaxAttempts: 3,
+				BaseDelayMs: 20,
+				MaxDelayMs:  500,
+			},
+			Replicas: ReplicaConfig{
+				MaxLagSeconds:        10,
+				StickySeconds:        5,
+				CheckIntervalSeconds: 10,
+			},
 		},
 		Logging: LoggingConfig{
-			Directory:         getEnv("LOG_DIRECTORY", "./logs"),
-			RetentionDays:     retentionDays,
-			CompressAfterDays: compressAfterDays,
-			Level:             getEnv("LOG_LEVEL", "info"),
+			Directory:         "./logs",
+			RetentionDays:     30,
+			CompressAfterDays: 7,
+			Level:             "info",
 		},
 		ServerLimits: ServerLimitsConfig{
-			RequestTimeoutSeconds: requestTimeout,
-			RateLimitRPS:          rateLimitRPS,
-			RateLimitBurst:        rateLimitBurst,
-			MaxRequestSizeMB:      maxRequestSizeMB,
+			RequestTimeoutSeconds: 30,
+			RateLimitRPS:          100,
+			RateLimitBurst:        200,
+			MaxRequestSizeMB:      10,
 		},
 		App: AppConfig{
-			IsProduction: isProduction,
+			Env: "development",
+		},
+		Reload: ReloadConfig{
+			WatchIntervalSeconds: 10,
+		},
+		Shutdown: ShutdownConfig{
+			DrainPeriodSeconds:     5,
+			HTTPTimeoutSeconds:     30,
+			JobsTimeoutSeconds:     10,
+			LogFlushTimeoutSeconds: 5,
+			DatabaseTimeoutSeconds: 5,
+		},
+		Outbox: OutboxConfig{
+			Publisher:             "none",
+			FilePath:              "./outbox.jsonl",
+			WebhookTimeoutSeconds: 5,
+			PollIntervalMs:        1000,
+			BatchSize:             100,
+			MaxAttempts:           10,
+			RetryMaxDelaySeconds:  600,
+			RetentionDays:         7,
 		},
 	}
+}
 
-	return cfg, nil
+// applyEnv overrides config values with environment variables.
+// Values that cannot be parsed are recorded in errs instead of being ignored.
+func applyEnv(cfg *Config, errs *ValidationErrors) {
+	env := envReader{errs: errs}
+
+	// Server
+	env.String("SERVER_PORT", &cfg.Server.Port)
+	env.String("SERVER_HOST", &cfg.Server.Host)
+	env.String("SERVER_NETWORK", &cfg.Server.Network)
+	env.String("SERVER_SOCKET_PATH", &cfg.Server.SocketPath)
+	env.String("SERVER_SOCKET_MODE", &cfg.Server.SocketMode)
+	env.String("CORS_ORIGINS", &cfg.Server.CORSOrigins)
+	env.Int("SERVER_READ_TIMEOUT_SECONDS", "server.read_timeout_seconds", &cfg.Server.ReadTimeoutSeconds)
+	env.Int("SERVER_READ_HEADER_TIMEOUT_SECONDS", "server.read_header_timeout_seconds", &cfg.Server.ReadHeaderTimeoutSeconds)
+	env.Int("SERVER_WRITE_TIMEOUT_SECONDS", "server.write_timeout_seconds", &cfg.Server.WriteTimeoutSeconds)
+	env.Int("SERVER_IDLE_TIMEOUT_SECONDS", "server.idle_timeout_seconds", &cfg.Server.IdleTimeoutSeconds)
+	env.Int("SERVER_MAX_HEADER_BYTES", "server.max_header_bytes", &cfg.Server.MaxHeaderBytes)
+	env.Bool("SERVER_KEEP_ALIVE", "server.keep_alive", &cfg.Server.KeepAlive)
+
+	// TLS
+	env.Bool("SERVER_TLS_ENABLED", "server.tls.enabled", &cfg.Server.TLS.Enabled)
+	env.String("SERVER_TLS_CERT_FILE", &cfg.Server.TLS.CertFile)
+	env.String("SERVER_TLS_KEY_FILE", &cfg.Server.TLS.KeyFile)
+	env.String("SERVER_TLS_MIN_VERSION", &cfg.Server.TLS.MinVersion)
+	env.StringList("SERVER_TLS_CIPHER_SUITES", &cfg.Server.TLS.CipherSuites)
+	env.String("SERVER_TLS_CLIENT_CA_FILE", &cfg.Server.TLS.ClientCAFile)
+	env.String("SERVER_TLS_CLIENT_AUTH", &cfg.Server.TLS.ClientAuth)
+	env.Int("SERVER_TLS_RELOAD_INTERVAL_SECONDS", "server.tls.reload_interval_seconds", &cfg.Server.TLS.ReloadIntervalSeconds)
+
+	// Admin listener
+	env.String("ADMIN_PORT", &cfg.Server.Admin.Port)
+	env.String("ADMIN_HOST", &cfg.Server.Admin.Host)
+	env.Bool("ADMIN_PPROF", "server.admin.pprof", &cfg.Server.Admin.Pprof)
+
+	// Database
+	env.String("DB_DRIVER", &cfg.Database.Driver)
+	env.String("DB_HOST", &cfg.Database.Host)
+	env.String("DB_PORT", &cfg.Database.Port)
+	env.String("DB_USER", &cfg.Database.User)
+	env.String("DB_NAME", &cfg.Database.DBName)
+	env.String("DB_CHARSET", &cfg.Database.Charset)
+	env.String("DB_SSLMODE", &cfg.Database.SSLMode)
+	env.String("DB_MIGRATIONS_DIR", &cfg.Database.MigrationsDir)
+	env.Bool("DB_MIGRATE_ON_START", "database.migrate_on_start", &cfg.Database.MigrateOnStart)
+	env.Bool("DB_AUTO_MIGRATE", "database.auto_migrate", &cfg.Database.AutoMigrate)
+	env.Int("DB_MAX_OPEN_CONNS", "database.max_open_conns", &cfg.Database.MaxOpenConns)
+	env.Int("DB_MAX_IDLE_CONNS", "database.max_idle_conns", &cfg.Database.MaxIdleConns)
+	env.Int("DB_CONN_MAX_LIFETIME_SECONDS", "database.conn_max_lifetime_seconds", &cfg.Database.ConnMaxLifetimeSeconds)
+	env.Int("DB_CONN_MAX_IDLE_TIME_SECONDS", "database.conn_max_idle_time_seconds", &cfg.Database.ConnMaxIdleTimeSeconds)
+	env.Int("DB_CONNECT_TIMEOUT_SECONDS", "database.connect_timeout_seconds", &cfg.Database.ConnectTimeoutSeconds)
+	env.Int("DB_SLOW_QUERY_MS", "database.slow_query_ms", &cfg.Database.SlowQueryMs)
+	env.Bool("DB_LOG_REDACT_PARAMS", "database.redact_params", &cfg.Database.RedactParams)
+	env.Int("DB_SOFT_DELETE_RETENTION_DAYS", "database.soft_delete_retention_days", &cfg.Database.SoftDeleteRetentionDays)
+	env.Int("DB_TX_RETRY_MAX_ATTEMPTS", "database.tx_retry.max_attempts", &cfg.Database.TxRetry.MaxAttempts)
+	env.Int("DB_TX_RETRY_BASE_DELAY_MS", "database.tx_retry.base_delay_ms", &cfg.Database.TxRetry.BaseDelayMs)
+	env.Int("DB_TX_RETRY_MAX_DELAY_MS", "database.tx_retry.max_delay_ms", &cfg.Database.TxRetry.MaxDelayMs)
+	env.StringList("DB_REPLICAS", &cfg.Database.Replicas.Hosts)
+	env.Int("DB_REPLICA_MAX_LAG_SECONDS", "database.replicas.max_lag_seconds", &cfg.Database.Replicas.MaxLagSeconds)
+	env.Int("DB_REPLICA_STICKY_SECONDS", "database.replicas.sticky_seconds", &cfg.Database.Replicas.StickySeconds)
+	env.Int("DB_REPLICA_CHECK_INTERVAL_SECONDS", "database.replicas.check_interval_seconds", &cfg.Database.Replicas.CheckIntervalSeconds)
+
+	// Logging
+	env.String("LOG_DIRECTORY", &cfg.Logging.Directory)
+	env.Int("LOG_RETENTION_DAYS", "logging.retention_days", &cfg.Logging.RetentionDays)
+	env.Int("LOG_COMPRESS_AFTER_DAYS", "logging.compress_after_days", &cfg.Logging.CompressAfterDays)
+	env.String("LOG_LEVEL", &cfg.Logging.Level)
+
+	// Server limits
+	env.Int("REQUEST_TIMEOUT_SECONDS", "server_limits.request_timeout_seconds", &cfg.ServerLimits.RequestTimeoutSeconds)
+	env.Float("RATE_LIMIT_RPS", "server_limits.rate_limit_rps", &cfg.ServerLimits.RateLimitRPS)
+	env.Int("RATE_LIMIT_BURST", "server_limits.rate_limit_burst", &cfg.ServerLimits.RateLimitBurst)
+	env.Int("MAX_REQUEST_SIZE_MB", "server_limits.max_request_size_mb", &cfg.ServerLimits.MaxRequestSizeMB)
+
+	// App (CURSOR_SECRET is resolved by the SecretProvider, see resolveSecrets)
+	env.String("ENV", &cfg.App.Env)
+
+	// Reload
+	env.Int("CONFIG_WATCH_INTERVAL_SECONDS", "reload.watch_interval_seconds", &cfg.Reload.WatchIntervalSeconds)
+
+	// Secrets (DB_PASSWORD and friends are resolved by the SecretProvider, see resolveSecrets)
+	env.String("SECRETS_FILE", &cfg.Secrets.File)
+	env.String("SECRETS_KEY_FILE", &cfg.Secrets.KeyFile)
+
+	// Shutdown
+	env.Int("SHUTDOWN_DRAIN_PERIOD_SECONDS", "shutdown.drain_period_seconds", &cfg.Shutdown.DrainPeriodSeconds)
+	env.Int("SHUTDOWN_HTTP_TIMEOUT_SECONDS", "shutdown.http_timeout_seconds", &cfg.Shutdown.HTTPTimeoutSeconds)
+	env.Int("SHUTDOWN_JOBS_TIMEOUT_SECONDS", "shutdown.jobs_timeout_seconds", &cfg.Shutdown.JobsTimeoutSeconds)
+	env.Int("SHUTDOWN_LOG_FLUSH_TIMEOUT_SECONDS", "shutdown.log_flush_timeout_seconds", &cfg.Shutdown.LogFlushTimeoutSeconds)
+	env.Int("SHUTDOWN_DATABASE_TIMEOUT_SECONDS", "shutdown.database_timeout_seconds", &cfg.Shutdown.DatabaseTimeoutSeconds)
+
+	// Outbox (OUTBOX_WEBHOOK_SECRET is resolved by the SecretProvider, see resolveSecrets)
+	env.String("OUTBOX_PUBLISHER", &cfg.Outbox.Publisher)
+	env.String("OUTBOX_FILE_PATH", &cfg.Outbox.FilePath)
+	env.String("OUTBOX_WEBHOOK_URL", &cfg.Outbox.WebhookURL)
+	env.Int("OUTBOX_WEBHOOK_TIMEOUT_SECONDS", "outbox.webhook_timeout_seconds", &cfg.Outbox.WebhookTimeoutSeconds)
+	env.Int("OUTBOX_POLL_INTERVAL_MS", "outbox.poll_interval_ms", &cfg.Outbox.PollIntervalMs)
+	env.Int("OUTBOX_BATCH_SIZE", "outbox.batch_size", &cfg.Outbox.BatchSize)
+	env.Int("OUTBOX_MAX_ATTEMPTS", "outbox.max_attempts", &cfg.Outbox.MaxAttempts)
+	env.Int("OUTBOX_RETRY_MAX_DELAY_SECONDS", "outbox.retry_max_delay_seconds", &cfg.Outbox.RetryMaxDelaySeconds)
+	env.Int("OUTBOX_RETENTION_DAYS", "outbox.retention_days", &cfg.Outbox.RetentionDays)
+}
+
+// normalize cleans up values and fills derived fields
+func (c *Config) normalize() {
+	c.Logging.Level = strings.ToLower(strings.TrimSpace(c.Logging.Level))
+	c.App.Env = strings.ToLower(strings.TrimSpace(c.App.Env))
+	c.App.IsProduction = c.App.Env == "production" || c.App.Env == "prod"
+	c.Server.Network = strings.ToLower(strings.TrimSpace(c.Server.Network))
+	c.Outbox.Publisher = strings.ToLower(strings.TrimSpace(c.Outbox.Publisher))
+	c.Server.TLS.ClientAuth = strings.ToLower(strings.TrimSpace(c.Server.TLS.ClientAuth))
+	if c.Server.TLS.ClientAuth == "" {
+		// A client CA bundle without explicit mode means mutual TLS
+		c.Server.TLS.ClientAuth = "none"
+		if c.Server.TLS.ClientCAFile != "" {
+			c.Server.TLS.ClientAuth = "require_and_verify"
+		}
+	}
+
+	c.Database.Driver = strings.ToLower(strings.TrimSpace(c.Database.Driver))
+	switch c.Database.Driver {
+	case "postgresql":
+		c.Database.Driver = DriverPostgres
+	case "sqlite3":
+		c.Database.Driver = DriverSQLite
+	}
+	if c.Database.Port == "" {
+		// Default port of the selected driver
+		switch c.Database.Driver {
+		case DriverMySQL:
+			c.Database.Port = "3306"
+		case DriverPostgres:
+			c.Database.Port = "5432"
+		}
+	}
 }
 
+// File returns the path of the config file this config was loaded from, or "" if none
+func (c *Config) File() string {
+	return c.file
+}
+
+// String returns the effective config as YAML with all secrets redacted
+func (c *Config) String() string {
+	out, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Sprintf("<config: %v>", err)
+	}
+	return string(out)
+}
+
+// DSN returns the connection string including the plain text password.
+// Never log it; use RedactedDSN instead.
 func (d DatabaseConfig) DSN() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=True&loc=Local",
-		d.User, d.Password, d.Host, d.Port, d.DBName, d.Charset)
+	return d.dsn(d.Password.Value())
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+// RedactedDSN returns the connection string with the password redacted, safe for logging
+func (d DatabaseConfig) RedactedDSN() string {
+	return d.dsn(d.Password.String())
+}
+
+func (d DatabaseConfig) dsn(password string) string {
+	switch d.Driver {
+	case DriverPostgres:
+		return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+			pgQuote(d.Host), pgQuote(d.Port), pgQuote(d.User), pgQuote(password), pgQuote(d.DBName), pgQuote(d.SSLMode))
+	case DriverSQLite:
+		// Enforce foreign keys and wait for locks instead of failing with SQLITE_BUSY
+		if strings.Contains(d.DBName, "?") {
+			return d.DBName
+		}
+		return d.DBName + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	default:
+		return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=True&loc=Local",
+			d.User, password, d.Host, d.Port, d.DBName, d.Charset)
+	}
+}
+
+// pgQuote quotes a value for a PostgreSQL key/value connection string
+func pgQuote(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
 		return value
 	}
-	return defaultValue
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// envReader reads typed values from environment variables and collects parse errors
+type envReader struct {
+	errs *ValidationErrors
+}
+
+// String overrides dst when the variable is set and non-empty
+func (r envReader) String(key string, dst *string) {
+	if value := os.Getenv(key); value != "" {
+		*dst = value
+	}
+}
+
+// StringList overrides dst with a comma-separated list when the variable is set
+func (r envReader) StringList(key string, dst *[]string) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			list = append(list, trimmed)
+		}
+	}
+	*dst = list
+}
+
+// Int overrides dst when the variable is set and is a valid integer
+func (r envReader) Int(key, field string, dst *int) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+	parsed, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		r.errs.Add(field, fmt.Sprintf("invalid value %q from %s: must be an integer", value, key))
+		return
+	}
+	*dst = parsed
+}
+
+// Float overrides dst when the variable is set and is a valid number
+func (r envReader) Float(key, field string, dst *float64) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		r.errs.Add(field, fmt.Sprintf("invalid value %q from %s: must be a number", value, key))
+		return
+	}
+	*dst = parsed
+}
+
+// Bool overrides dst when the variable is set and is a valid boolean
+func (r envReader) Bool(key, field string, dst *bool) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+	parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		r.errs.Add(field, fmt.Sprintf("invalid value %q from %s: must be true or false", value, key))
+		return
+	}
+	*dst = parsed
 }
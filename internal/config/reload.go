@@ -0,0 +1,250 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"llm-aggregator/internal/logger"
+)
+
+// redactedValue replaces secret values in diffs and printed configs
+const redactedValue = "<redacted>"
+
+// Change describes a single setting that differs between two configs
+type Change struct {
+	Field      string // Dotted config key, e.g. "server_limits.rate_limit_rps"
+	Old        string
+	New        string
+	Reloadable bool // Whether the change can be applied without a restart
+}
+
+// Diff returns every setting that differs between oldCfg and newCfg.
+// Secret values are compared in plain text but redacted in the result.
+func Diff(oldCfg, newCfg *Config) []Change {
+	var changes []Change
+	walkFields(reflect.ValueOf(oldCfg).Elem(), reflect.ValueOf(newCfg).Elem(), "", func(field string, sf reflect.StructField, oldV, newV reflect.Value) {
+		if reflect.DeepEqual(oldV.Interface(), newV.Interface()) {
+			return
+		}
+		// Secret values print as redacted
+		oldStr, newStr := fmt.Sprint(oldV.Interface()), fmt.Sprint(newV.Interface())
+		changes = append(changes, Change{
+			Field:      field,
+			Old:        oldStr,
+			New:        newStr,
+			Reloadable: sf.Tag.Get("reloadable") == "true",
+		})
+	})
+	return changes
+}
+
+// walkFields calls fn for every leaf field of two config structs of the same type.
+// Fields are named by their dotted yaml keys; fields tagged yaml:"-" are skipped.
+func walkFields(a, b reflect.Value, prefix string, fn func(field string, sf reflect.StructField, a, b reflect.Value)) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if !sf.IsExported() || name == "-" || name == "" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if sf.Type.Kind() == reflect.Struct {
+			walkFields(a.Field(i), b.Field(i), name, fn)
+			continue
+		}
+		fn(name, sf, a.Field(i), b.Field(i))
+	}
+}
+
+// withReloadable returns a copy of base with every reloadable field taken from next
+func withReloadable(base, next *Config) *Config {
+	merged := *base
+	walkFields(reflect.ValueOf(&merged).Elem(), reflect.ValueOf(next).Elem(), "", func(_ string, sf reflect.StructField, dst, src reflect.Value) {
+		if sf.Tag.Get("reloadable") == "true" {
+			dst.Set(src)
+		}
+	})
+	return &merged
+}
+
+// ReloadFunc applies a configuration to a live component.
+// It is called with the full effective config after every accepted reload.
+type ReloadFunc func(cfg *Config) error
+
+// Reloader re-reads the configuration on SIGHUP or when the config file changes
+// and applies runtime-tunable settings to registered components.
+//
+// Only fields tagged `reloadable:"true"` are applied; other changes are logged
+// as requiring a restart. Invalid configs are rejected and the current config is kept.
+//
+// Usage:
+//
+//	reloader := config.NewReloader(cfg)
+//	reloader.OnReload(func(cfg *config.Config) error {
+//	    tunables.Apply(cfg)
+//	    return nil
+//	})
+//	go reloader.Watch(ctx)
+type Reloader struct {
+	mu      sync.Mutex
+	current atomic.Pointer[Config]
+	hooks   []ReloadFunc
+}
+
+// NewReloader creates a reloader starting from the given config
+func NewReloader(cfg *Config) *Reloader {
+	r := &Reloader{}
+	r.current.Store(cfg)
+	return r
+}
+
+// OnReload registers a function called with the new config after each accepted reload
+func (r *Reloader) OnReload(fn ReloadFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, fn)
+}
+
+// Current returns the currently active config
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// Reload loads the config again and applies reloadable changes.
+// If loading, validation or any hook fails, the current config stays active.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	log := logger.GetLogger()
+	old := r.current.Load()
+
+	next, err := LoadFile(old.File())
+	if err != nil {
+		log.Error("Config reload rejected, keeping current config", zap.Error(err))
+		return err
+	}
+
+	var reloadable []Change
+	for _, change := range Diff(old, next) {
+		if change.Reloadable {
+			reloadable = append(reloadable, change)
+		} else {
+			log.Warn("Config value changed but requires a restart, ignored",
+				zap.String("field", change.Field),
+				zap.String("old", change.Old),
+				zap.String("new", change.New),
+			)
+		}
+	}
+
+	if len(reloadable) == 0 {
+		log.Info("Config reloaded, no runtime-tunable changes")
+		return nil
+	}
+
+	// Reloadable values must also be consistent with the settings that stay in effect
+	// (e.g. a longer request timeout than the running server write timeout)
+	effective := withReloadable(old, next)
+	if errs := effective.Validate(); len(errs) > 0 {
+		logChanges(log.Warn, "Config reload rejected change", reloadable)
+		log.Error("Config reload rejected, keeping current config", zap.Error(errs))
+		return errs
+	}
+
+	if err := r.apply(effective); err != nil {
+		logChanges(log.Warn, "Config reload rejected change", reloadable)
+		log.Error("Config reload failed, restoring previous config", zap.Error(err))
+		if restoreErr := r.apply(old); restoreErr != nil {
+			log.Error("Failed to restore previous config", zap.Error(restoreErr))
+		}
+		return err
+	}
+
+	r.current.Store(effective)
+	logChanges(log.Info, "Config value changed", reloadable)
+	log.Info("Config reloaded", zap.Int("applied_changes", len(reloadable)))
+	return nil
+}
+
+// logChanges logs each change with msg at the level of logf, once a reload is applied or rejected
+func logChanges(logf func(msg string, fields ...zap.Field), msg string, changes []Change) {
+	for _, change := range changes {
+		logf(msg,
+			zap.String("field", change.Field),
+			zap.String("old", change.Old),
+			zap.String("new", change.New),
+		)
+	}
+}
+
+func (r *Reloader) apply(cfg *Config) error {
+	for _, hook := range r.hooks {
+		if err := hook(cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Watch reloads the config on SIGHUP and, if a config file is used and
+// reload.watch_interval_seconds > 0, whenever the file changes on disk.
+// It blocks until ctx is cancelled.
+func (r *Reloader) Watch(ctx context.Context) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	cfg := r.Current()
+	var poll <-chan time.Time
+	if cfg.File() != "" && cfg.Reload.WatchIntervalSeconds > 0 {
+		ticker := time.NewTicker(time.Duration(cfg.Reload.WatchIntervalSeconds) * time.Second)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+	lastStamp := FileStamp(cfg.File())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			logger.GetLogger().Info("SIGHUP received, reloading config")
+			_ = r.Reload()
+		case <-poll:
+			stamp := FileStamp(cfg.File())
+			if stamp == lastStamp {
+				continue
+			}
+			lastStamp = stamp
+			logger.GetLogger().Info("Config file changed, reloading config", zap.String("file", cfg.File()))
+			_ = r.Reload()
+		}
+	}
+}
+
+// FileStamp returns a value that changes whenever the file is modified.
+// It is empty when path is empty or the file cannot be accessed.
+func FileStamp(path string) string {
+	if path == "" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+// Secret is a string that never reveals its value when printed, logged or marshaled.
+// Use Value() to get the plain text, e.g. when building a DSN.
+type Secret string
+
+// Value returns the plain text secret
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String implements fmt.Stringer; the value is always redacted
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+// GoString implements fmt.GoStringer so %#v is redacted too
+func (s Secret) GoString() string {
+	return fmt.Sprintf("%q", s.String())
+}
+
+// MarshalJSON redacts the secret in JSON output (including zap reflection encoding)
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%q", s.String())), nil
+}
+
+// MarshalYAML redacts the secret in YAML output
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// MarshalText redacts the secret in text based encoders (e.g. TOML)
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// SecretProvider resolves secrets by name (e.g. "DB_PASSWORD").
+// Implementations must not log secret values.
+type SecretProvider interface {
+	// GetSecret returns the value for key. found is false when the provider has no value for key.
+	GetSecret(key string) (value string, found bool, err error)
+}
+
+// EnvSecretProvider reads secrets from environment variables named after the key
+type EnvSecretProvider struct{}
+
+// GetSecret implements SecretProvider
+func (EnvSecretProvider) GetSecret(key string) (string, bool, error) {
+	value := os.Getenv(key)
+	return value, value != "", nil
+}
+
+// FileSecretProvider reads secrets from files referenced by <KEY>_FILE environment variables,
+// e.g. DB_PASSWORD_FILE=/run/secrets/db_password. A trailing newline is removed.
+type FileSecretProvider struct{}
+
+// GetSecret implements SecretProvider
+func (FileSecretProvider) GetSecret(key string) (string, bool, error) {
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read secret file from %s_FILE: %w", key, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// EncryptedFileSecretProvider reads secrets from an AES-256-GCM encrypted file.
+// The decrypted content uses .env syntax (KEY=VALUE per line).
+// The file holds base64(nonce || ciphertext) as produced by EncryptSecrets.
+type EncryptedFileSecretProvider struct {
+	path    string
+	key     []byte
+	once    sync.Once
+	secrets map[string]string
+	err     error
+}
+
+// NewEncryptedFileSecretProvider creates a provider for the encrypted file at path,
+// using the 32 byte key stored (hex or base64 encoded) in keyPath
+func NewEncryptedFileSecretProvider(path, keyPath string) (*EncryptedFileSecretProvider, error) {
+	key, err := ReadSecretKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedFileSecretProvider{path: path, key: key}, nil
+}
+
+// GetSecret implements SecretProvider. The file is decrypted once, on first use.
+func (p *EncryptedFileSecretProvider) GetSecret(key string) (string, bool, error) {
+	p.once.Do(p.load)
+	if p.err != nil {
+		return "", false, p.err
+	}
+	value, found := p.secrets[key]
+	return value, found, nil
+}
+
+func (p *EncryptedFileSecretProvider) load() {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		p.err = fmt.Errorf("failed to read encrypted secrets file: %w", err)
+		return
+	}
+	plaintext, err := DecryptSecrets(data, p.key)
+	if err != nil {
+		p.err = err
+		return
+	}
+	p.secrets, p.err = godotenv.Unmarshal(string(plaintext))
+	if p.err != nil {
+		p.err = fmt.Errorf("failed to parse decrypted secrets: %w", p.err)
+	}
+}
+
+// ReadSecretKey reads a 32 byte AES key stored hex or base64 encoded in path
+func ReadSecretKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets key file: %w", err)
+	}
+	encoded := strings.TrimSpace(string(data))
+
+	key, err := hex.DecodeString(encoded)
+	if err != nil {
+		key, err = base64.StdEncoding.DecodeString(encoded)
+	}
+	if err != nil {
+		return nil, errors.New("secrets key must be hex or base64 encoded")
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secrets key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// EncryptSecrets encrypts plaintext (.env syntax) with AES-256-GCM and returns
+// base64(nonce || ciphertext), the format read by EncryptedFileSecretProvider
+func EncryptSecrets(plaintext, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+	return []byte(base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// DecryptSecrets reverses EncryptSecrets
+func DecryptSecrets(data, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("encrypted secrets file is not valid base64: %w", err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("encrypted secrets file is too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt secrets file (wrong key or corrupted file)")
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// ChainSecretProvider asks each provider in order and returns the first value found
+type ChainSecretProvider []SecretProvider
+
+// GetSecret implements SecretProvider
+func (c ChainSecretProvider) GetSecret(key string) (string, bool, error) {
+	for _, provider := range c {
+		value, found, err := provider.GetSecret(key)
+		if err != nil || found {
+			return value, found, err
+		}
+	}
+	return "", false, nil
+}
+
+// customSecretProvider replaces the default provider chain when set
+var (
+	customSecretProvider   SecretProvider
+	customSecretProviderMu sync.RWMutex
+)
+
+// SetSecretProvider replaces the default secret provider chain (env, *_FILE, encrypted file).
+// Pass nil to restore the default. Must be called before Load.
+func SetSecretProvider(provider SecretProvider) {
+	customSecretProviderMu.Lock()
+	defer customSecretProviderMu.Unlock()
+	customSecretProvider = provider
+}
+
+// secretProvider returns the provider used to resolve secrets for cfg
+func secretProvider(cfg *Config, errs *ValidationErrors) SecretProvider {
+	customSecretProviderMu.RLock()
+	defer customSecretProviderMu.RUnlock()
+	if customSecretProvider != nil {
+		return customSecretProvider
+	}
+
+	chain := ChainSecretProvider{EnvSecretProvider{}, FileSecretProvider{}}
+	// A missing key file is reported by Validate
+	if cfg.Secrets.File != "" && cfg.Secrets.KeyFile != "" {
+		encrypted, err := NewEncryptedFileSecretProvider(cfg.Secrets.File, cfg.Secrets.KeyFile)
+		if err != nil {
+			errs.Add("secrets.key_file", err.Error())
+		} else {
+			chain = append(chain, encrypted)
+		}
+	}
+	return chain
+}
+
+// resolveSecrets fills secret fields from the secret provider.
+// Values from the config file are kept when no provider has the secret.
+func resolveSecrets(cfg *Config, errs *ValidationErrors) {
+	provider := secretProvider(cfg, errs)
+	resolveSecret(provider, errs, "DB_PASSWORD", "database.password", &cfg.Database.Password)
+	resolveSecret(provider, errs, "OUTBOX_WEBHOOK_SECRET", "outbox.webhook_secret", &cfg.Outbox.WebhookSecret)
+	resolveSecret(provider, errs, "CURSOR_SECRET", "app.cursor_secret", &cfg.App.CursorSecret)
+}
+
+func resolveSecret(provider SecretProvider, errs *ValidationErrors, key, field string, dst *Secret) {
+	if os.Getenv(key) != "" && os.Getenv(key+"_FILE") != "" {
+		errs.Add(field, fmt.Sprintf("both %s and %s_FILE are set, use only one", key, key))
+		return
+	}
+	value, found, err := provider.GetSecret(key)
+	if err != nil {
+		errs.Add(field, err.Error())
+		return
+	}
+	if found {
+		*dst = Secret(value)
+	}
+}
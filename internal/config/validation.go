@@ -0,0 +1,362 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// validLogLevels lists the log levels accepted by the logger
+var validLogLevels = []string{"debug", "info", "warn", "error"}
+
+// validEnvs lists the accepted application environments
+var validEnvs = []string{"development", "dev", "staging", "stage", "production", "prod"}
+
+// validNetworks lists the accepted listener types
+var validNetworks = []string{"tcp", "unix", "systemd"}
+
+// validDrivers lists the supported database drivers
+var validDrivers = []string{DriverMySQL, DriverPostgres, DriverSQLite}
+
+// validSSLModes lists the accepted PostgreSQL sslmode values
+var validSSLModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// validTLSVersions lists the accepted minimum TLS versions
+var validTLSVersions = []string{"1.2", "1.3"}
+
+// validClientAuth lists the accepted client certificate modes
+var validClientAuth = []string{"none", "request", "require", "verify_if_given", "require_and_verify"}
+
+// validOutboxPublishers lists the accepted outbox publishers
+var validOutboxPublishers = []string{"none", "stdout", "file", "webhook"}
+
+// FieldError describes a single invalid configuration value
+type FieldError struct {
+	Field   string // Dotted config key, e.g. "database.host"
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors aggregates every problem found while loading the configuration
+type ValidationErrors []FieldError
+
+// Add appends a new field error
+func (v *ValidationErrors) Add(field, message string) {
+	*v = append(*v, FieldError{Field: field, Message: message})
+}
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+	for i, e := range v {
+		messages[i] = e.Error()
+	}
+	return fmt.Sprintf("invalid configuration (%d errors):\n  - %s", len(v), strings.Join(messages, "\n  - "))
+}
+
+// Validate checks the configuration for invalid or inconsistent values.
+// It returns every problem found rather than stopping at the first one.
+func (c *Config) Validate() ValidationErrors {
+	var errs ValidationErrors
+
+	// Server
+	c.validateListener(&errs)
+	c.validateServerTimeouts(&errs)
+	c.validateTLS(&errs)
+	if c.Server.AdminEnabled() {
+		validatePort(&errs, "server.admin.port", c.Server.Admin.Port)
+		if c.Server.Network == "tcp" && c.Server.Admin.Port == c.Server.Port {
+			errs.Add("server.admin.port", fmt.Sprintf("must differ from server.port (%s)", c.Server.Port))
+		}
+	}
+
+	// Database
+	c.validateDatabase(&errs)
+
+	// Logging
+	if strings.TrimSpace(c.Logging.Directory) == "" {
+		errs.Add("logging.directory", "must not be empty")
+	}
+	if c.Logging.RetentionDays < 0 {
+		errs.Add("logging.retention_days", fmt.Sprintf("must be >= 0, got %d", c.Logging.RetentionDays))
+	}
+	if c.Logging.CompressAfterDays < 0 {
+		errs.Add("logging.compress_after_days", fmt.Sprintf("must be >= 0, got %d", c.Logging.CompressAfterDays))
+	}
+	if !contains(validLogLevels, c.Logging.Level) {
+		errs.Add("logging.level", fmt.Sprintf("unknown log level %q (valid: %s)", c.Logging.Level, strings.Join(validLogLevels, ", ")))
+	}
+
+	// Server limits
+	if c.ServerLimits.RequestTimeoutSeconds <= 0 {
+		errs.Add("server_limits.request_timeout_seconds", fmt.Sprintf("must be > 0, got %d", c.ServerLimits.RequestTimeoutSeconds))
+	}
+	if c.ServerLimits.RateLimitRPS <= 0 {
+		errs.Add("server_limits.rate_limit_rps", fmt.Sprintf("must be > 0, got %g", c.ServerLimits.RateLimitRPS))
+	}
+	if c.ServerLimits.RateLimitBurst <= 0 {
+		errs.Add("server_limits.rate_limit_burst", fmt.Sprintf("must be > 0, got %d", c.ServerLimits.RateLimitBurst))
+	}
+	if c.ServerLimits.MaxRequestSizeMB < 0 {
+		errs.Add("server_limits.max_request_size_mb", fmt.Sprintf("must be >= 0, got %d", c.ServerLimits.MaxRequestSizeMB))
+	}
+
+	// App
+	if !contains(validEnvs, c.App.Env) {
+		errs.Add("app.env", fmt.Sprintf("unknown environment %q (valid: %s)", c.App.Env, strings.Join(validEnvs, ", ")))
+	}
+
+	// Reload
+	if c.Reload.WatchIntervalSeconds < 0 {
+		errs.Add("reload.watch_interval_seconds", fmt.Sprintf("must be >= 0, got %d", c.Reload.WatchIntervalSeconds))
+	}
+
+	// Shutdown
+	validateNonNegative(&errs, "shutdown.drain_period_seconds", c.Shutdown.DrainPeriodSeconds)
+	validatePositive(&errs, "shutdown.http_timeout_seconds", c.Shutdown.HTTPTimeoutSeconds)
+	validatePositive(&errs, "shutdown.jobs_timeout_seconds", c.Shutdown.JobsTimeoutSeconds)
+	validatePositive(&errs, "shutdown.log_flush_timeout_seconds", c.Shutdown.LogFlushTimeoutSeconds)
+	validatePositive(&errs, "shutdown.database_timeout_seconds", c.Shutdown.DatabaseTimeoutSeconds)
+
+	// Outbox
+	c.validateOutbox(&errs)
+
+	// Secrets
+	if c.Secrets.File != "" && c.Secrets.KeyFile == "" {
+		errs.Add("secrets.key_file", "must be set when secrets.file is set")
+	}
+
+	return errs
+}
+
+// validateDatabase checks the connection settings required by the selected driver
+func (c *Config) validateDatabase(errs *ValidationErrors) {
+	db := c.Database
+	if strings.TrimSpace(db.DBName) == "" {
+		errs.Add("database.name", "must not be empty")
+	}
+
+	switch db.Driver {
+	case DriverMySQL, DriverPostgres:
+		if strings.TrimSpace(db.Host) == "" {
+			errs.Add("database.host", "must not be empty")
+		}
+		validatePort(errs, "database.port", db.Port)
+		if strings.TrimSpace(db.User) == "" {
+			errs.Add("database.user", "must not be empty")
+		}
+		if db.Driver == DriverPostgres && !contains(validSSLModes, db.SSLMode) {
+			errs.Add("database.sslmode", fmt.Sprintf("unknown sslmode %q (valid: %s)", db.SSLMode, strings.Join(validSSLModes, ", ")))
+		}
+	case DriverSQLite:
+		// Only the file path (database.name) is used
+		if len(db.Replicas.Hosts) > 0 {
+			errs.Add("database.replicas.hosts", "read replicas are not supported with sqlite")
+		}
+	default:
+		errs.Add("database.driver", fmt.Sprintf("unknown driver %q (valid: %s)", db.Driver, strings.Join(validDrivers, ", ")))
+	}
+
+	if db.AutoMigrate && db.MigrateOnStart {
+		errs.Add("database.auto_migrate", "must not be combined with database.migrate_on_start")
+	}
+
+	validateNonNegative(errs, "database.max_open_conns", db.MaxOpenConns)
+	validateNonNegative(errs, "database.max_idle_conns", db.MaxIdleConns)
+	validateNonNegative(errs, "database.conn_max_lifetime_seconds", db.ConnMaxLifetimeSeconds)
+	validateNonNegative(errs, "database.conn_max_idle_time_seconds", db.ConnMaxIdleTimeSeconds)
+	validateNonNegative(errs, "database.connect_timeout_seconds", db.ConnectTimeoutSeconds)
+	if db.MaxOpenConns > 0 && db.MaxIdleConns > db.MaxOpenConns {
+		errs.Add("database.max_idle_conns", fmt.Sprintf("must not exceed database.max_open_conns (%d)", db.MaxOpenConns))
+	}
+	validatePositive(errs, "database.tx_retry.max_attempts", db.TxRetry.MaxAttempts)
+	validateNonNegative(errs, "database.tx_retry.base_delay_ms", db.TxRetry.BaseDelayMs)
+	validateNonNegative(errs, "database.tx_retry.max_delay_ms", db.TxRetry.MaxDelayMs)
+	if db.TxRetry.MaxDelayMs < db.TxRetry.BaseDelayMs {
+		errs.Add("database.tx_retry.max_delay_ms", fmt.Sprintf("must not be less than database.tx_retry.base_delay_ms (%d)", db.TxRetry.BaseDelayMs))
+	}
+	validateNonNegative(errs, "database.slow_query_ms", db.SlowQueryMs)
+	validateNonNegative(errs, "database.soft_delete_retention_days", db.SoftDeleteRetentionDays)
+
+	for i, host := range db.Replicas.Hosts {
+		field := fmt.Sprintf("database.replicas.hosts[%d]", i)
+		replica := db.ForReplica(host)
+		if strings.TrimSpace(replica.Host) == "" {
+			errs.Add(field, "must not be empty")
+			continue
+		}
+		validatePort(errs, field, replica.Port)
+	}
+	if len(db.Replicas.Hosts) > 0 {
+		validatePositive(errs, "database.replicas.max_lag_seconds", db.Replicas.MaxLagSeconds)
+		validateNonNegative(errs, "database.replicas.sticky_seconds", db.Replicas.StickySeconds)
+		validatePositive(errs, "database.replicas.check_interval_seconds", db.Replicas.CheckIntervalSeconds)
+	}
+}
+
+// validateOutbox checks the relay settings and those required by the selected publisher
+func (c *Config) validateOutbox(errs *ValidationErrors) {
+	o := c.Outbox
+	switch o.Publisher {
+	case "none", "stdout":
+	case "file":
+		if strings.TrimSpace(o.FilePath) == "" {
+			errs.Add("outbox.file_path", "must be set when outbox.publisher is file")
+		}
+	case "webhook":
+		if u, err := url.Parse(o.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.Add("outbox.webhook_url", fmt.Sprintf("must be an http(s) URL when outbox.publisher is webhook, got %q", o.WebhookURL))
+		}
+	default:
+		errs.Add("outbox.publisher", fmt.Sprintf("unknown publisher %q (valid: %s)", o.Publisher, strings.Join(validOutboxPublishers, ", ")))
+	}
+
+	validatePositive(errs, "outbox.webhook_timeout_seconds", o.WebhookTimeoutSeconds)
+	validatePositive(errs, "outbox.poll_interval_ms", o.PollIntervalMs)
+	validatePositive(errs, "outbox.batch_size", o.BatchSize)
+	validatePositive(errs, "outbox.max_attempts", o.MaxAttempts)
+	validatePositive(errs, "outbox.retry_max_delay_seconds", o.RetryMaxDelaySeconds)
+	validateNonNegative(errs, "outbox.retention_days", o.RetentionDays)
+}
+
+// validateListener checks the public listener settings for the selected network
+func (c *Config) validateListener(errs *ValidationErrors) {
+	switch c.Server.Network {
+	case "tcp":
+		validatePort(errs, "server.port", c.Server.Port)
+	case "unix":
+		if strings.TrimSpace(c.Server.SocketPath) == "" {
+			errs.Add("server.socket_path", "must be set when server.network is unix")
+		}
+		if _, err := c.Server.SocketFileMode(); err != nil {
+			errs.Add("server.socket_mode", err.Error())
+		}
+	case "systemd":
+		// The inherited socket is checked when the server starts
+	default:
+		errs.Add("server.network", fmt.Sprintf("unknown network %q (valid: %s)", c.Server.Network, strings.Join(validNetworks, ", ")))
+	}
+}
+
+// validateServerTimeouts checks the http.Server settings and their consistency
+// with the request timeout middleware
+func (c *Config) validateServerTimeouts(errs *ValidationErrors) {
+	server := c.Server
+	validateNonNegative(errs, "server.read_timeout_seconds", server.ReadTimeoutSeconds)
+	validateNonNegative(errs, "server.read_header_timeout_seconds", server.ReadHeaderTimeoutSeconds)
+	validateNonNegative(errs, "server.write_timeout_seconds", server.WriteTimeoutSeconds)
+	validateNonNegative(errs, "server.idle_timeout_seconds", server.IdleTimeoutSeconds)
+
+	if server.MaxHeaderBytes <= 0 {
+		errs.Add("server.max_header_bytes", fmt.Sprintf("must be > 0, got %d", server.MaxHeaderBytes))
+	}
+
+	// ReadHeaderTimeout is part of ReadTimeout, so it cannot be longer
+	if server.ReadTimeoutSeconds > 0 && server.ReadHeaderTimeoutSeconds > server.ReadTimeoutSeconds {
+		errs.Add("server.read_header_timeout_seconds", fmt.Sprintf(
+			"must be <= server.read_timeout_seconds (%d), got %d",
+			server.ReadTimeoutSeconds, server.ReadHeaderTimeoutSeconds))
+	}
+
+	// The write timeout must leave room for the timeout middleware to send its 504 response,
+	// otherwise the connection is closed before the app timeout fires
+	if server.WriteTimeoutSeconds > 0 && server.WriteTimeoutSeconds <= c.ServerLimits.RequestTimeoutSeconds {
+		errs.Add("server.write_timeout_seconds", fmt.Sprintf(
+			"must be greater than server_limits.request_timeout_seconds (%d), got %d",
+			c.ServerLimits.RequestTimeoutSeconds, server.WriteTimeoutSeconds))
+	}
+}
+
+// validateTLS checks the TLS settings. File contents are checked when the server starts.
+func (c *Config) validateTLS(errs *ValidationErrors) {
+	t := c.Server.TLS
+	validateNonNegative(errs, "server.tls.reload_interval_seconds", t.ReloadIntervalSeconds)
+	if !contains(validClientAuth, t.ClientAuth) {
+		errs.Add("server.tls.client_auth", fmt.Sprintf("unknown client auth mode %q (valid: %s)", t.ClientAuth, strings.Join(validClientAuth, ", ")))
+	}
+	if !t.Enabled {
+		return
+	}
+
+	validateFileExists(errs, "server.tls.cert_file", t.CertFile)
+	validateFileExists(errs, "server.tls.key_file", t.KeyFile)
+	if t.ClientCAFile != "" {
+		validateFileExists(errs, "server.tls.client_ca_file", t.ClientCAFile)
+	}
+	if (t.ClientAuth == "verify_if_given" || t.ClientAuth == "require_and_verify") && t.ClientCAFile == "" {
+		errs.Add("server.tls.client_ca_file", fmt.Sprintf("must be set when server.tls.client_auth is %q", t.ClientAuth))
+	}
+
+	if !contains(validTLSVersions, t.MinVersion) {
+		errs.Add("server.tls.min_version", fmt.Sprintf("unknown TLS version %q (valid: %s)", t.MinVersion, strings.Join(validTLSVersions, ", ")))
+	}
+	if len(t.CipherSuites) > 0 && t.MinVersion == "1.3" {
+		errs.Add("server.tls.cipher_suites", "not configurable with TLS 1.3, remove it or set server.tls.min_version to 1.2")
+	}
+	for _, name := range t.CipherSuites {
+		if _, ok := TLSCipherSuite(name); !ok {
+			errs.Add("server.tls.cipher_suites", fmt.Sprintf("unknown or insecure cipher suite %q", name))
+		}
+	}
+}
+
+// TLSCipherSuite returns the ID of a secure cipher suite by its IANA name
+func TLSCipherSuite(name string) (uint16, bool) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+	return 0, false
+}
+
+// validateFileExists checks that path is set and points to a readable file
+func validateFileExists(errs *ValidationErrors, field, path string) {
+	if strings.TrimSpace(path) == "" {
+		errs.Add(field, "must not be empty")
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		errs.Add(field, fmt.Sprintf("cannot access file: %v", err))
+	}
+}
+
+// validateNonNegative checks that value is >= 0
+func validateNonNegative(errs *ValidationErrors, field string, value int) {
+	if value < 0 {
+		errs.Add(field, fmt.Sprintf("must be >= 0, got %d", value))
+	}
+}
+
+// validatePositive checks that value is > 0
+func validatePositive(errs *ValidationErrors, field string, value int) {
+	if value <= 0 {
+		errs.Add(field, fmt.Sprintf("must be > 0, got %d", value))
+	}
+}
+
+// validatePort checks that value is a TCP port number in range 1-65535
+func validatePort(errs *ValidationErrors, field, value string) {
+	port, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		errs.Add(field, fmt.Sprintf("invalid port %q: must be a number", value))
+		return
+	}
+	if port < 1 || port > 65535 {
+		errs.Add(field, fmt.Sprintf("port %d out of range (1-65535)", port))
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,330 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"llm-aggregator/internal/entity"
+	"llm-aggregator/internal/logger"
+)
+
+// auditPluginName is the name of the audit trail GORM plugin
+const auditPluginName = "llm-aggregator:audit"
+
+// auditBeforeKey holds the rows an update or delete is about to change in its instance settings
+const auditBeforeKey = "audit:before"
+
+// SystemActor is the actor of changes made outside HTTP requests without WithActor
+const SystemActor = "system"
+
+type actorKey struct{}
+
+// WithActor returns a context whose changes are recorded in audit_logs as made by actor,
+// e.g. "user:alice". middleware.AuditActor sets it per request.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the actor set by WithActor, or SystemActor
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
+
+// auditPlugin records the creates, updates and deletes of entity.Auditable entities in audit_logs,
+// with the changed columns before and after, the actor and the request ID.
+//
+// It covers every statement run with a model (Create, Save, Updates, Delete, ...), whatever
+// repository runs it; Exec and Raw SQL are not audited. Updates and deletes read the rows they
+// match before and after running, in the transaction of the change, locking them on MySQL and
+// PostgreSQL. The entries are written in that transaction too (GORM wraps each write in one),
+// so a failed entry fails the change.
+type auditPlugin struct{}
+
+// Name implements gorm.Plugin
+func (auditPlugin) Name() string {
+	return auditPluginName
+}
+
+// Initialize implements gorm.Plugin
+func (auditPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").
+			Register("audit:create", auditCreate),
+		callbacks.Update().After("gorm:setup_reflect_value").Before("gorm:update").
+			Register("audit:load_before", auditLoadBefore),
+		callbacks.Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").
+			Register("audit:update", auditChange(entity.AuditActionUpdate)),
+		callbacks.Delete().After("gorm:begin_transaction").Before("gorm:delete").
+			Register("audit:load_before", auditLoadBefore),
+		callbacks.Delete().After("gorm:delete").Before("gorm:commit_or_rollback_transaction").
+			Register("audit:delete", auditChange(entity.AuditActionDelete)),
+	)
+}
+
+// auditedEntity returns the entity type of the statement model, or "" when it is not audited
+func auditedEntity(db *gorm.DB) string {
+	if db.DryRun || db.Statement.Schema == nil || db.Statement.Schema.PrioritizedPrimaryField == nil {
+		return ""
+	}
+	if auditable, ok := reflect.New(db.Statement.Schema.ModelType).Interface().(entity.Auditable); ok {
+		return auditable.AuditEntity()
+	}
+	return ""
+}
+
+func auditCreate(db *gorm.DB) {
+	entityType := auditedEntity(db)
+	if entityType == "" || db.Error != nil || db.Statement.RowsAffected == 0 {
+		return
+	}
+
+	var logs []entity.AuditLog
+	err := eachRow(db.Statement.ReflectValue, func(row reflect.Value) error {
+		id, after, err := auditSnapshot(db.Statement, row)
+		if err != nil {
+			return err
+		}
+		logs = append(logs, newAuditLog(db, entityType, id, entity.AuditActionCreate, nil, after))
+		return nil
+	})
+	if err == nil {
+		err = writeAuditLogs(db, logs)
+	}
+	if err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+	}
+}
+
+// auditLoadBefore reads the rows an update or delete matches, to diff them afterwards
+func auditLoadBefore(db *gorm.DB) {
+	if auditedEntity(db) == "" || db.Error != nil {
+		return
+	}
+
+	stmt := db.Statement
+	tx := auditSession(db).Model(reflect.New(stmt.Schema.ModelType).Interface())
+	if stmt.Unscoped {
+		tx = tx.Unscoped()
+	}
+	conditions := 0
+	if where, ok := stmt.Clauses["WHERE"].Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+		tx.Statement.AddClause(where)
+		conditions++
+	}
+	// Conditions GORM adds from the primary key of the model or value (e.g. Save, Delete(&order))
+	for _, value := range []reflect.Value{stmt.ReflectValue, reflect.ValueOf(stmt.Model)} {
+		if !value.IsValid() || value.Kind() == reflect.Ptr && value.IsNil() {
+			continue
+		}
+		_, queryValues := schema.GetIdentityFieldValuesMap(stmt.Context, reflect.Indirect(value), stmt.Schema.PrimaryFields)
+		column, values := schema.ToQueryValues(stmt.Schema.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+		if len(values) > 0 {
+			tx = tx.Where(clause.IN{Column: column, Values: values})
+			conditions++
+		}
+	}
+	// GORM refuses to update or delete without conditions unless AllowGlobalUpdate is set
+	if conditions == 0 && !db.AllowGlobalUpdate {
+		return
+	}
+
+	// Lock the rows until the change commits, so they are the version the statement changes: a
+	// plain read may see an older snapshot (MySQL REPEATABLE READ) or race another writer.
+	// SQLite allows a single writer, and has no FOR UPDATE.
+	switch db.Dialector.Name() {
+	case "mysql", "postgres":
+		tx = tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate})
+	}
+
+	rows := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+	if err := tx.Find(rows.Interface()).Error; err != nil {
+		db.AddError(fmt.Errorf("audit: failed to read the rows before the change: %w", err))
+		return
+	}
+	db.InstanceSet(auditBeforeKey, rows.Elem())
+}
+
+// auditChange records the rows loaded by auditLoadBefore that the update or delete changed.
+// action is the default action: an update clearing deleted_at is a restore, and a delete that
+// removed the row (Unscoped, or an entity without soft delete) is a purge.
+func auditChange(action string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		entityType := auditedEntity(db)
+		if entityType == "" || db.Error != nil || db.Statement.RowsAffected == 0 {
+			return
+		}
+		value, ok := db.InstanceGet(auditBeforeKey)
+		if !ok {
+			return
+		}
+		rows := value.(reflect.Value)
+		if rows.Len() == 0 {
+			return
+		}
+
+		stmt := db.Statement
+		before := make(map[string]map[string]json.RawMessage, rows.Len())
+		ids := make([]string, 0, rows.Len())
+		err := eachRow(rows, func(row reflect.Value) error {
+			id, snapshot, err := auditSnapshot(stmt, row)
+			before[id] = snapshot
+			ids = append(ids, id)
+			return err
+		})
+		if err != nil {
+			db.AddError(fmt.Errorf("audit: %w", err))
+			return
+		}
+
+		afterRows := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+		err = auditSession(db).Unscoped().
+			Where(clause.IN{Column: clause.Column{Name: stmt.Schema.PrioritizedPrimaryField.DBName}, Values: toInterfaces(ids)}).
+			Find(afterRows.Interface()).Error
+		if err != nil {
+			db.AddError(fmt.Errorf("audit: failed to read the rows after the change: %w", err))
+			return
+		}
+		after := make(map[string]map[string]json.RawMessage, afterRows.Elem().Len())
+		err = eachRow(afterRows.Elem(), func(row reflect.Value) error {
+			id, snapshot, err := auditSnapshot(stmt, row)
+			after[id] = snapshot
+			return err
+		})
+		if err != nil {
+			db.AddError(fmt.Errorf("audit: %w", err))
+			return
+		}
+
+		var logs []entity.AuditLog
+		for _, id := range ids {
+			rowAction := action
+			oldValues, newValues := before[id], after[id]
+			if newValues == nil {
+				rowAction = entity.AuditActionPurge
+			} else {
+				oldValues, newValues = changedColumns(oldValues, newValues)
+				if len(newValues) == 0 {
+					continue // Matched but not changed, e.g. restoring a row that is not deleted
+				}
+				if action == entity.AuditActionUpdate && isRestore(oldValues, newValues) {
+					rowAction = entity.AuditActionRestore
+				}
+			}
+			logs = append(logs, newAuditLog(db, entityType, id, rowAction, oldValues, newValues))
+		}
+		if err := writeAuditLogs(db, logs); err != nil {
+			db.AddError(fmt.Errorf("audit: %w", err))
+		}
+	}
+}
+
+// auditSession returns a new session on the connection of the statement, in its transaction,
+// reading from the primary
+func auditSession(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true, Context: WithPrimary(db.Statement.Context)})
+}
+
+// auditSnapshot returns the primary key of row and its columns encoded as JSON
+func auditSnapshot(stmt *gorm.Statement, row reflect.Value) (string, map[string]json.RawMessage, error) {
+	primaryKey, _ := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, row)
+	snapshot := make(map[string]json.RawMessage, len(stmt.Schema.DBNames))
+	for _, name := range stmt.Schema.DBNames {
+		value, _ := stmt.Schema.FieldsByDBName[name].ValueOf(stmt.Context, row)
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to encode column %s: %w", name, err)
+		}
+		snapshot[name] = encoded
+	}
+	return fmt.Sprint(primaryKey), snapshot, nil
+}
+
+// changedColumns returns the columns that differ between before and after
+func changedColumns(before, after map[string]json.RawMessage) (oldValues, newValues map[string]json.RawMessage) {
+	oldValues = make(map[string]json.RawMessage)
+	newValues = make(map[string]json.RawMessage)
+	for name, value := range after {
+		if !bytes.Equal(before[name], value) {
+			oldValues[name] = before[name]
+			newValues[name] = value
+		}
+	}
+	return oldValues, newValues
+}
+
+// isRestore reports whether an update cleared deleted_at
+func isRestore(oldValues, newValues map[string]json.RawMessage) bool {
+	oldDeletedAt, changed := oldValues["deleted_at"]
+	return changed && string(oldDeletedAt) != "null" && string(newValues["deleted_at"]) == "null"
+}
+
+func newAuditLog(db *gorm.DB, entityType, id, action string, oldValues, newValues map[string]json.RawMessage) entity.AuditLog {
+	ctx := db.Statement.Context
+	return entity.AuditLog{
+		ID:         uuid.New().String(),
+		EntityType: entityType,
+		EntityID:   id,
+		Action:     action,
+		Actor:      Actor(ctx),
+		RequestID:  logger.GetRequestID(ctx),
+		OldValues:  encodeColumns(oldValues),
+		NewValues:  encodeColumns(newValues),
+	}
+}
+
+// encodeColumns returns columns as a JSON object, or "" when there are none
+func encodeColumns(columns map[string]json.RawMessage) string {
+	if len(columns) == 0 {
+		return ""
+	}
+	encoded, _ := json.Marshal(columns) // Values are valid JSON already
+	return string(encoded)
+}
+
+func writeAuditLogs(db *gorm.DB, logs []entity.AuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	if err := auditSession(db).Create(&logs).Error; err != nil {
+		return fmt.Errorf("failed to write audit logs: %w", err)
+	}
+	return nil
+}
+
+// eachRow calls fn with value, or with each element when value is a slice or array
+func eachRow(value reflect.Value, fn func(row reflect.Value) error) error {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := fn(reflect.Indirect(value.Index(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		return fn(value)
+	}
+	return nil
+}
+
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
@@ -1,45 +1,161 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	glogger "gorm.io/gorm/logger"
 
 	"llm-aggregator/internal/config"
 	"llm-aggregator/internal/entity"
+	"llm-aggregator/internal/logger"
 )
 
-func NewConnection(cfg config.DatabaseConfig) (*gorm.DB, error) {
-	dsn := cfg.DSN()
+// Backoff between attempts to reach the database at startup
+const (
+	connectBackoffInitial = 500 * time.Millisecond
+	connectBackoffMax     = 10 * time.Second
+)
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
+// NewConnection opens the database. While the server is not reachable (e.g. a container starting
+// before the database), it retries with exponential backoff for cfg.ConnectTimeoutSeconds, or
+// until ctx is done.
+func NewConnection(ctx context.Context, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := connect(ctx, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
 	}
 
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sql.DB: %w", err)
 	}
+	configurePool(sqlDB, cfg)
+
+	if err := db.Use(metricsPlugin{}); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to register query metrics: %w", err)
+	}
 
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	if err := db.Use(auditPlugin{}); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to register audit trail: %w", err)
+	}
+
+	if len(cfg.Replicas.Hosts) > 0 {
+		if err := setupReplicas(db, cfg); err != nil {
+			sqlDB.Close()
+			return nil, err
+		}
+	}
 
 	return db, nil
 }
 
-func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+// connect opens the database, retrying until the connect timeout or until ctx is done
+func connect(ctx context.Context, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	deadline := time.Now().Add(time.Duration(cfg.ConnectTimeoutSeconds) * time.Second)
+	backoff := connectBackoffInitial
+	for attempt := 1; ; attempt++ {
+		dialector, err := newDialector(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		// Failed attempts are logged below, not by GORM
+		db, err := gorm.Open(dialector, &gorm.Config{
+			Logger: newGormLogger(cfg).LogMode(glogger.Silent),
+		})
+		if err == nil {
+			db.Logger = newGormLogger(cfg)
+			return db, nil
+		}
+		if db != nil {
+			if sqlDB, dbErr := db.DB(); dbErr == nil {
+				sqlDB.Close()
+			}
+		}
+		// The last attempt is made at the deadline
+		wait := min(backoff, time.Until(deadline))
+		if wait <= 0 {
+			return nil, fmt.Errorf("failed to connect to database %s after %d attempts: %w", cfg.RedactedDSN(), attempt, err)
+		}
+
+		logger.GetLogger().Warn("Database not reachable, retrying",
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", wait),
+			zap.Error(err),
+		)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up connecting to database %s after %d attempts: %w", cfg.RedactedDSN(), attempt, ctx.Err())
+		case <-time.After(wait):
+		}
+		backoff = min(backoff*2, connectBackoffMax)
+	}
+}
+
+// configurePool applies the connection pool settings
+func configurePool(sqlDB *sql.DB, cfg config.DatabaseConfig) {
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetimeSeconds) * time.Second)
+	sqlDB.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTimeSeconds) * time.Second)
+	if cfg.Driver == config.DriverSQLite {
+		// SQLite allows a single writer, and every connection to :memory: opens a new empty database,
+		// so the one connection is kept open forever
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	}
+}
+
+// newDialector returns the GORM dialector of the configured driver.
+// SQLite uses a pure Go driver, so no C toolchain is needed.
+func newDialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case config.DriverMySQL:
+		return mysql.Open(cfg.DSN()), nil
+	case config.DriverPostgres:
+		return postgres.Open(cfg.DSN()), nil
+	case config.DriverSQLite:
+		return sqlite.Open(cfg.DSN()), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+}
+
+// Models returns the entities managed by AutoMigrate and compared by DiffSchema
+func Models() []interface{} {
+	return []interface{}{
 		&entity.User{},
 		&entity.Order{},
+		&entity.AuditLog{},
+		&entity.OutboxEvent{},
 		// Add other entities here
-	)
+	}
+}
+
+// ErrManagedByMigrations is returned by AutoMigrate on a database whose schema the SQL migrations manage
+var ErrManagedByMigrations = errors.New("the schema is managed by the SQL migrations (" + migrationsTable + " exists), use migrate up")
+
+// AutoMigrate creates and updates the tables of Models on the primary. It refuses to touch a
+// database the Migrator manages: the migrations would then fail on the tables it created.
+func AutoMigrate(db *gorm.DB) error {
+	db = db.WithContext(WithPrimary(context.Background()))
+	if db.Migrator().HasTable(migrationsTable) {
+		return ErrManagedByMigrations
+	}
+	return db.AutoMigrate(Models()...)
 }
 
 // Transaction executes a function within a database transaction
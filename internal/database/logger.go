@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gorm.io/gorm"
+	glogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+
+	"llm-aggregator/internal/config"
+	"llm-aggregator/internal/logger"
+)
+
+// gormLogger writes GORM logs through the application zap logger, so they follow LOG_LEVEL and
+// end up in the log files:
+//   - failed statements at error (record not found is not an error)
+//   - statements slower than the slow query threshold at warn
+//   - every other statement at debug, or at info in a db.Debug() session
+type gormLogger struct {
+	mode          glogger.LogLevel
+	slowThreshold time.Duration
+	redactParams  bool
+}
+
+// newGormLogger creates the GORM logger configured by cfg
+func newGormLogger(cfg config.DatabaseConfig) *gormLogger {
+	return &gormLogger{
+		mode:          glogger.Warn,
+		slowThreshold: time.Duration(cfg.SlowQueryMs) * time.Millisecond,
+		redactParams:  cfg.RedactParams,
+	}
+}
+
+// LogMode implements glogger.Interface. glogger.Info (db.Debug()) logs every statement at info.
+func (l *gormLogger) LogMode(mode glogger.LogLevel) glogger.Interface {
+	clone := *l
+	clone.mode = mode
+	return &clone
+}
+
+// Info implements glogger.Interface
+func (l *gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.mode >= glogger.Info {
+		l.log(ctx).Info(fmt.Sprintf(msg, args...))
+	}
+}
+
+// Warn implements glogger.Interface
+func (l *gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.mode >= glogger.Warn {
+		l.log(ctx).Warn(fmt.Sprintf(msg, args...))
+	}
+}
+
+// Error implements glogger.Interface
+func (l *gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.mode >= glogger.Error {
+		l.log(ctx).Error(fmt.Sprintf(msg, args...))
+	}
+}
+
+// Trace implements glogger.Interface, logging a finished statement
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.mode <= glogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	log := l.log(ctx)
+
+	var level zapcore.Level
+	var msg string
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.mode >= glogger.Error:
+		level, msg = zapcore.ErrorLevel, "Database query failed"
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.mode >= glogger.Warn:
+		level, msg = zapcore.WarnLevel, "Slow database query"
+	case l.mode >= glogger.Info:
+		level, msg = zapcore.InfoLevel, "Database query"
+	default:
+		level, msg = zapcore.DebugLevel, "Database query"
+	}
+
+	// fc builds the SQL with the parameters inlined, skip it when the entry is filtered out
+	entry := log.Check(level, msg)
+	if entry == nil {
+		return
+	}
+	sql, rows := fc()
+	fields := []zap.Field{
+		zap.String("sql", sql),
+		zap.Int64("rows", rows),
+		zap.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+		zap.String("source", utils.FileWithLineNum()),
+	}
+	if level == zapcore.WarnLevel {
+		fields = append(fields, zap.Int64("threshold_ms", l.slowThreshold.Milliseconds()))
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+	entry.Write(fields...)
+}
+
+// ParamsFilter implements gorm.ParamsFilter. With redaction on, logged SQL keeps the ? placeholders.
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.redactParams {
+		return sql, nil
+	}
+	return sql, params
+}
+
+// log returns the application logger with the request ID of ctx. The caller is reported in the
+// source field, as zap's caller would point into GORM.
+func (l *gormLogger) log(ctx context.Context) *zap.Logger {
+	log := logger.GetLogger().WithOptions(zap.WithCaller(false))
+	if ctx != nil {
+		if requestID := logger.GetRequestID(ctx); requestID != "" {
+			log = log.With(zap.String("request_id", requestID))
+		}
+	}
+	return log
+}
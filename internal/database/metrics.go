@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"llm-aggregator/internal/common"
+	"llm-aggregator/internal/metrics"
+)
+
+// metricsPluginName is the name of the query metrics GORM plugin
+const metricsPluginName = "llm-aggregator:metrics"
+
+// metricsStartKey holds the start time of a statement in its instance settings
+const metricsStartKey = "metrics:start"
+
+// PoolStatsInterval is the interval at which connection pool stats are published
+const PoolStatsInterval = 15 * time.Second
+
+// metricsPlugin records database_queries_total, database_query_duration_seconds and
+// database_errors_total for every statement, labelled by operation and table
+type metricsPlugin struct{}
+
+// Name implements gorm.Plugin
+func (metricsPlugin) Name() string {
+	return metricsPluginName
+}
+
+// Initialize implements gorm.Plugin, wrapping every callback chain with a timer
+func (metricsPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("*").Register("metrics:start", startTimer),
+		callbacks.Create().After("*").Register("metrics:record", recordQuery("create")),
+		callbacks.Query().Before("*").Register("metrics:start", startTimer),
+		callbacks.Query().After("*").Register("metrics:record", recordQuery("query")),
+		callbacks.Update().Before("*").Register("metrics:start", startTimer),
+		callbacks.Update().After("*").Register("metrics:record", recordQuery("update")),
+		callbacks.Delete().Before("*").Register("metrics:start", startTimer),
+		callbacks.Delete().After("*").Register("metrics:record", recordQuery("delete")),
+		callbacks.Row().Before("*").Register("metrics:start", startTimer),
+		callbacks.Row().After("*").Register("metrics:record", recordQuery("row")),
+		callbacks.Raw().Before("*").Register("metrics:start", startTimer),
+		callbacks.Raw().After("*").Register("metrics:record", recordQuery("raw")),
+	)
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(metricsStartKey, time.Now())
+}
+
+func recordQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		// DryRun statements (e.g. schema diff drafts) never reach the database
+		if db.DryRun {
+			return
+		}
+		value, ok := db.InstanceGet(metricsStartKey)
+		if !ok {
+			return
+		}
+		start, _ := value.(time.Time)
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		metrics.DatabaseQueriesTotal.WithLabelValues(operation, table).Inc()
+		metrics.DatabaseQueryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+		if db.Error != nil {
+			metrics.DatabaseErrorsTotal.WithLabelValues(operation, table, string(common.ClassifyDBError(db.Error))).Inc()
+		}
+	}
+}
+
+// CollectPoolStats publishes the connection pool stats of the primary every interval until ctx is done
+func CollectPoolStats(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return
+	}
+
+	var last sql.DBStats
+	publish := func() {
+		stats := sqlDB.Stats()
+		metrics.DatabaseConnections.Set(float64(stats.OpenConnections))
+		metrics.DatabaseConnectionsInUse.Set(float64(stats.InUse))
+		metrics.DatabaseConnectionsIdle.Set(float64(stats.Idle))
+		// WaitCount and WaitDuration are cumulative, the counters get the increase since the last run
+		metrics.DatabaseConnectionWaitsTotal.Add(float64(stats.WaitCount - last.WaitCount))
+		metrics.DatabaseConnectionWaitSeconds.Add((stats.WaitDuration - last.WaitDuration).Seconds())
+		last = stats
+	}
+
+	publish()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			publish()
+		}
+	}
+}
@@ -1,227 +1,515 @@
 package database
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
-	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"go.uber.org/zap"
 	"gorm.io/gorm"
+
+	"llm-aggregator/internal/logger"
 )
 
-// Migration represents a database migration
+// migrationsTable records applied migrations
+const migrationsTable = "schema_migrations"
+
+// Migration represents a database migration.
+// SQL migrations are loaded from .up.sql/.down.sql files (see LoadMigrations);
+// Go migrations are registered with RegisterMigration and set Up/Down.
 type Migration struct {
 	Version     string
 	Description string
-	Up          func(*gorm.DB) error
+	Up          func(*gorm.DB) error // Go migration; nil for SQL migrations
 	Down        func(*gorm.DB) error
+	UpSQL       []string // Statements of the .up.sql file
+	DownSQL     []string // Statements of the .down.sql file
+	Checksum    string   // SHA-256 of the .up.sql file; empty for Go migrations
+}
+
+func (m Migration) name() string {
+	return m.Version + "_" + m.Description
+}
+
+func (m Migration) hasDown() bool {
+	return m.Down != nil || len(m.DownSQL) > 0
 }
 
 var migrations []Migration
 
-// RegisterMigration registers a new migration
+// RegisterMigration registers a Go migration, run together with the SQL migrations
 func RegisterMigration(migration Migration) {
 	migrations = append(migrations, migration)
 }
 
-// RunMigrations runs all pending migrations
-func RunMigrations(db *gorm.DB) error {
-	sqlDB, err := db.DB()
+// MigratorOptions configures a Migrator
+type MigratorOptions struct {
+	DryRun      bool          // Print the SQL plan to Output instead of executing it
+	Output      io.Writer     // Destination of the dry-run plan (default os.Stdout)
+	LockTimeout time.Duration // Max wait for the migration lock held by another process (default 60s)
+	AllowDrift  bool          // Continue when applied migration files were modified afterwards
+}
+
+// Migrator applies and rolls back migrations.
+//
+// Applied versions are recorded in schema_migrations with the checksum of their .up.sql file,
+// so files edited after being applied are detected (drift). On MySQL and PostgreSQL an advisory
+// lock makes concurrent replicas wait for each other instead of racing.
+//
+// Each migration runs in a transaction together with its schema_migrations record. Note that
+// MySQL commits DDL statements implicitly (PostgreSQL and SQLite do not), so a failing
+// multi-statement migration may leave the statements before the failing one applied;
+// the error reports which statement failed.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	opts       MigratorOptions
+}
+
+// NewMigrator creates a migrator for the SQL migrations in source plus the registered Go migrations
+func NewMigrator(db *gorm.DB, source fs.FS, opts MigratorOptions) (*Migrator, error) {
+	loaded, err := LoadMigrations(source, db.Dialector.Name())
 	if err != nil {
-		return fmt.Errorf("failed to get sql.DB: %w", err)
+		return nil, err
 	}
 
-	// Create migrations table if it doesn't exist
-	if err := createMigrationsTable(sqlDB); err != nil {
-		return fmt.Errorf("failed to create migrations table: %w", err)
+	all := append(loaded, migrations...)
+	sortMigrations(all)
+	for i := 1; i < len(all); i++ {
+		if compareVersions(all[i-1].Version, all[i].Version) == 0 {
+			return nil, fmt.Errorf("duplicate migration version %s (%s, %s)", all[i].Version, all[i-1].Description, all[i].Description)
+		}
 	}
 
-	// Get applied migrations
-	applied, err := getAppliedMigrations(sqlDB)
-	if err != nil {
-		return fmt.Errorf("failed to get applied migrations: %w", err)
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	if opts.LockTimeout <= 0 {
+		opts.LockTimeout = 60 * time.Second
 	}
 
-	// Run pending migrations
-	for _, migration := range migrations {
-		if isApplied(applied, migration.Version) {
-			continue
+	// Migrations inspect and change the schema of the primary, never of a read replica
+	db = db.WithContext(WithPrimary(context.Background()))
+	return &Migrator{db: db, migrations: all, opts: opts}, nil
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	Version     string
+	Description string
+	Checksum    string
+	AppliedAt   time.Time
+}
+
+// MigrationState describes a migration and whether it is applied
+type MigrationState struct {
+	Version     string
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+	Modified    bool // The .up.sql file changed after it was applied
+	Missing     bool // Applied, but no longer present in the migration files
+}
+
+// migrationStep is one migration to run in a plan
+type migrationStep struct {
+	migration Migration
+	up        bool
+}
+
+// Up applies every pending migration.
+// Pending migrations older than the latest applied one (e.g. from a merged branch) are applied too.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.migrate(ctx, func(applied map[string]appliedMigration) ([]migrationStep, error) {
+		var plan []migrationStep
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; !ok {
+				plan = append(plan, migrationStep{migration: migration, up: true})
+			}
 		}
+		return plan, nil
+	})
+}
 
-		// Run migration
-		if err := migration.Up(db); err != nil {
-			return fmt.Errorf("failed to run migration %s: %w", migration.Version, err)
+// Down rolls back the n most recently applied migrations, newest version first
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	if n < 1 {
+		return fmt.Errorf("number of migrations to roll back must be >= 1, got %d", n)
+	}
+	return m.migrate(ctx, func(applied map[string]appliedMigration) ([]migrationStep, error) {
+		versions := appliedVersions(applied)
+		if len(versions) == 0 {
+			return nil, errors.New("no migrations to roll back")
+		}
+		if n > len(versions) {
+			n = len(versions)
 		}
 
-		// Record migration
-		if err := recordMigration(sqlDB, migration.Version, migration.Description); err != nil {
-			return fmt.Errorf("failed to record migration %s: %w", migration.Version, err)
+		var plan []migrationStep
+		for _, version := range versions[len(versions)-n:] {
+			migration, err := m.rollbackMigration(version)
+			if err != nil {
+				return nil, err
+			}
+			plan = append([]migrationStep{{migration: migration}}, plan...)
 		}
+		return plan, nil
+	})
+}
+
+// To migrates up or down so that exactly the migrations up to and including version are applied.
+// Version "0" rolls back every migration.
+func (m *Migrator) To(ctx context.Context, version string) error {
+	if version != "0" && m.find(version) == nil {
+		return fmt.Errorf("migration %s not found", version)
 	}
+	return m.migrate(ctx, func(applied map[string]appliedMigration) ([]migrationStep, error) {
+		var plan []migrationStep
+
+		// Roll back newer migrations, newest first
+		versions := appliedVersions(applied)
+		for i := len(versions) - 1; i >= 0; i-- {
+			if compareVersions(versions[i], version) <= 0 {
+				continue
+			}
+			migration, err := m.rollbackMigration(versions[i])
+			if err != nil {
+				return nil, err
+			}
+			plan = append(plan, migrationStep{migration: migration})
+		}
 
-	return nil
+		// Apply pending migrations up to version, oldest first
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; !ok && compareVersions(migration.Version, version) <= 0 {
+				plan = append(plan, migrationStep{migration: migration, up: true})
+			}
+		}
+		return plan, nil
+	})
 }
 
-// RollbackMigration rolls back the last migration
-func RollbackMigration(db *gorm.DB) error {
-	sqlDB, err := db.DB()
+// Status returns every known migration in version order, including applied versions
+// whose files no longer exist
+func (m *Migrator) Status(ctx context.Context) ([]MigrationState, error) {
+	applied, err := m.readApplied(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get sql.DB: %w", err)
+		return nil, err
 	}
 
-	// Get last applied migration
-	lastMigration, err := getLastMigration(sqlDB)
-	if err != nil {
-		return fmt.Errorf("failed to get last migration: %w", err)
+	states := make([]MigrationState, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		state := MigrationState{Version: migration.Version, Description: migration.Description}
+		if record, ok := applied[migration.Version]; ok {
+			state.Applied = true
+			state.AppliedAt = record.AppliedAt
+			state.Modified = isModified(migration, record)
+		}
+		states = append(states, state)
 	}
-
-	if lastMigration == "" {
-		return fmt.Errorf("no migrations to rollback")
+	for _, record := range applied {
+		if m.find(record.Version) == nil {
+			states = append(states, MigrationState{
+				Version:     record.Version,
+				Description: record.Description,
+				Applied:     true,
+				AppliedAt:   record.AppliedAt,
+				Missing:     true,
+			})
+		}
 	}
+	sort.SliceStable(states, func(i, j int) bool {
+		return compareVersions(states[i].Version, states[j].Version) < 0
+	})
+	return states, nil
+}
 
-	// Find migration
-	var migration *Migration
-	for i := len(migrations) - 1; i >= 0; i-- {
-		if migrations[i].Version == lastMigration {
-			migration = &migrations[i]
-			break
+// migrate computes a plan from the applied migrations and runs it while holding the migration lock.
+// In dry-run mode the plan is printed instead and nothing is written.
+func (m *Migrator) migrate(ctx context.Context, planFn func(map[string]appliedMigration) ([]migrationStep, error)) error {
+	if !m.opts.DryRun {
+		unlock, err := m.lock(ctx)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if err := m.createMigrationsTable(); err != nil {
+			return err
 		}
 	}
 
-	if migration == nil {
-		return fmt.Errorf("migration %s not found", lastMigration)
+	// Read the state only once the lock is held, another replica may just have migrated
+	applied, err := m.readApplied(ctx)
+	if err != nil {
+		return err
+	}
+	if err := m.checkDrift(applied); err != nil {
+		return err
 	}
 
-	// Rollback migration
-	if err := migration.Down(db); err != nil {
-		return fmt.Errorf("failed to rollback migration %s: %w", migration.Version, err)
+	plan, err := planFn(applied)
+	if err != nil {
+		return err
 	}
 
-	// Remove migration record
-	if err := removeMigration(sqlDB, lastMigration); err != nil {
-		return fmt.Errorf("failed to remove migration record: %w", err)
+	if m.opts.DryRun {
+		return m.printPlan(plan)
 	}
 
+	log := logger.GetLogger()
+	if len(plan) == 0 {
+		log.Info("Database schema is up to date")
+		return nil
+	}
+	for _, step := range plan {
+		start := time.Now()
+		if err := m.runStep(ctx, step); err != nil {
+			return err
+		}
+		action := "Migration applied"
+		if !step.up {
+			action = "Migration rolled back"
+		}
+		log.Info(action, zap.String("version", step.migration.Version),
+			zap.String("name", step.migration.Description),
+			zap.Duration("duration", time.Since(start)),
+		)
+	}
 	return nil
 }
 
-// createMigrationsTable creates the migrations table
-func createMigrationsTable(db *sql.DB) error {
-	query := `
-		CREATE TABLE IF NOT EXISTS schema_migrations (
-			version VARCHAR(255) PRIMARY KEY,
-			description VARCHAR(255),
-			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)
-	`
-	_, err := db.Exec(query)
-	return err
+// runStep runs one migration and updates schema_migrations in the same transaction
+func (m *Migrator) runStep(ctx context.Context, step migrationStep) error {
+	migration := step.migration
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if step.up {
+			err = runMigrationCode(tx, migration.Up, migration.UpSQL)
+		} else {
+			err = runMigrationCode(tx, migration.Down, migration.DownSQL)
+		}
+		if err != nil {
+			direction := "run"
+			if !step.up {
+				direction = "roll back"
+			}
+			return fmt.Errorf("failed to %s migration %s: %w", direction, migration.name(), err)
+		}
+
+		if step.up {
+			err = tx.Exec("INSERT INTO "+migrationsTable+" (version, description, checksum, applied_at) VALUES (?, ?, ?, ?)",
+				migration.Version, migration.Description, migration.Checksum, time.Now().UTC()).Error
+		} else {
+			err = tx.Exec("DELETE FROM "+migrationsTable+" WHERE version = ?", migration.Version).Error
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", migration.name(), err)
+		}
+		return nil
+	})
 }
 
-// getAppliedMigrations returns list of applied migration versions
-func getAppliedMigrations(db *sql.DB) ([]string, error) {
-	rows, err := db.Query("SELECT version FROM schema_migrations ORDER BY applied_at")
-	if err != nil {
-		return nil, err
+// runMigrationCode runs a Go migration function or the statements of a SQL migration
+func runMigrationCode(tx *gorm.DB, fn func(*gorm.DB) error, statements []string) error {
+	if fn != nil {
+		return fn(tx)
 	}
-	defer rows.Close()
+	for i, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return fmt.Errorf("statement %d of %d: %w", i+1, len(statements), err)
+		}
+	}
+	return nil
+}
 
-	var versions []string
-	for rows.Next() {
-		var version string
-		if err := rows.Scan(&version); err != nil {
-			return nil, err
+// printPlan writes the SQL that would run, without executing it
+func (m *Migrator) printPlan(plan []migrationStep) error {
+	w := m.opts.Output
+	if len(plan) == 0 {
+		_, err := fmt.Fprintln(w, "-- Nothing to do, database schema is up to date")
+		return err
+	}
+
+	fmt.Fprintf(w, "-- Migration plan (dry run): %d step(s)\n", len(plan))
+	for _, step := range plan {
+		migration := step.migration
+		statements, direction := migration.UpSQL, "up"
+		if !step.up {
+			statements, direction = migration.DownSQL, "down"
+		}
+
+		fmt.Fprintf(w, "\n-- [%s] %s\n", direction, migration.name())
+		if (step.up && migration.Up != nil) || (!step.up && migration.Down != nil) {
+			fmt.Fprintln(w, "-- (Go migration, SQL not available)")
+			continue
+		}
+		for _, statement := range statements {
+			fmt.Fprintf(w, "%s;\n", statement)
 		}
-		versions = append(versions, version)
 	}
+	return nil
+}
 
-	return versions, rows.Err()
+// rollbackMigration returns the migration used to roll back an applied version
+func (m *Migrator) rollbackMigration(version string) (Migration, error) {
+	migration := m.find(version)
+	if migration == nil {
+		return Migration{}, fmt.Errorf("cannot roll back %s: migration file not found", version)
+	}
+	if !migration.hasDown() {
+		return Migration{}, fmt.Errorf("cannot roll back %s: no down migration", migration.name())
+	}
+	return *migration, nil
 }
 
-// isApplied checks if a migration version is already applied
-func isApplied(applied []string, version string) bool {
-	for _, v := range applied {
-		if v == version {
-			return true
+func (m *Migrator) find(version string) *Migration {
+	for i := range m.migrations {
+		if compareVersions(m.migrations[i].Version, version) == 0 {
+			return &m.migrations[i]
 		}
 	}
-	return false
+	return nil
 }
 
-// recordMigration records a migration as applied
-func recordMigration(db *sql.DB, version, description string) error {
-	query := "INSERT INTO schema_migrations (version, description) VALUES (?, ?)"
-	_, err := db.Exec(query, version, description)
-	return err
-}
+// checkDrift fails when applied migrations were modified afterwards, unless AllowDrift is set.
+// Records created before checksums were stored are backfilled.
+func (m *Migrator) checkDrift(applied map[string]appliedMigration) error {
+	var modified []string
+	for _, record := range applied {
+		migration := m.find(record.Version)
+		if migration == nil || migration.Checksum == "" {
+			continue
+		}
+		if record.Checksum == "" {
+			if !m.opts.DryRun {
+				if err := m.db.Exec("UPDATE "+migrationsTable+" SET checksum = ? WHERE version = ?", migration.Checksum, record.Version).Error; err != nil {
+					return fmt.Errorf("failed to store checksum of migration %s: %w", record.Version, err)
+				}
+			}
+			continue
+		}
+		if isModified(*migration, record) {
+			modified = append(modified, migration.name())
+		}
+	}
 
-// getLastMigration returns the last applied migration version
-func getLastMigration(db *sql.DB) (string, error) {
-	var version string
-	err := db.QueryRow("SELECT version FROM schema_migrations ORDER BY applied_at DESC LIMIT 1").Scan(&version)
-	if err == sql.ErrNoRows {
-		return "", nil
+	if len(modified) == 0 {
+		return nil
 	}
-	return version, err
+	sort.Strings(modified)
+	if m.opts.AllowDrift {
+		logger.GetLogger().Warn("Applied migrations were modified, continuing because drift is allowed", zap.Strings("migrations", modified))
+		return nil
+	}
+	return fmt.Errorf("applied migrations were modified after being applied (checksum mismatch): %s; "+
+		"restore the original files and add a new migration instead", strings.Join(modified, ", "))
 }
 
-// removeMigration removes a migration record
-func removeMigration(db *sql.DB, version string) error {
-	_, err := db.Exec("DELETE FROM schema_migrations WHERE version = ?", version)
-	return err
+func isModified(migration Migration, record appliedMigration) bool {
+	return record.Checksum != "" && migration.Checksum != "" && record.Checksum != migration.Checksum
 }
 
-// LoadMigrationsFromFiles loads migrations from SQL files
-func LoadMigrationsFromFiles(db *gorm.DB, migrationsDir string) error {
-	if migrationsDir == "" {
-		migrationsDir = "./migrations"
+// migrationsTableDDL returns the CREATE TABLE statement of schema_migrations for a dialect
+func migrationsTableDDL(dialect string) string {
+	switch dialect {
+	case "mysql":
+		return `CREATE TABLE ` + migrationsTable + ` (
+			version VARCHAR(255) NOT NULL PRIMARY KEY,
+			description VARCHAR(255),
+			checksum VARCHAR(64),
+			applied_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`
+	case "postgres":
+		return `CREATE TABLE ` + migrationsTable + ` (
+			version VARCHAR(255) NOT NULL PRIMARY KEY,
+			description VARCHAR(255),
+			checksum VARCHAR(64),
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`
+	default:
+		return `CREATE TABLE ` + migrationsTable + ` (
+			version TEXT NOT NULL PRIMARY KEY,
+			description TEXT,
+			checksum TEXT,
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`
 	}
+}
 
-	// Check if directory exists
-	if _, err := os.Stat(migrationsDir); os.IsNotExist(err) {
-		return nil // No migrations directory, skip
+// createMigrationsTable creates schema_migrations, or adds the checksum column to a table created by older versions
+func (m *Migrator) createMigrationsTable() error {
+	if !m.db.Migrator().HasTable(migrationsTable) {
+		if err := m.db.Exec(migrationsTableDDL(m.db.Dialector.Name())).Error; err != nil {
+			return fmt.Errorf("failed to create migrations table: %w", err)
+		}
+		return nil
 	}
 
-	// Read migration files
-	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.up.sql"))
-	if err != nil {
-		return fmt.Errorf("failed to read migration files: %w", err)
+	if !m.db.Migrator().HasColumn(migrationsTable, "checksum") {
+		if err := m.db.Exec("ALTER TABLE " + migrationsTable + " ADD COLUMN checksum VARCHAR(64)").Error; err != nil {
+			return fmt.Errorf("failed to add checksum column to migrations table: %w", err)
+		}
 	}
+	return nil
+}
 
-	for _, file := range files {
-		version := filepath.Base(file)
-		version = version[:len(version)-7] // Remove .up.sql
+// readApplied returns the applied migrations by version. A missing table means none are applied.
+func (m *Migrator) readApplied(ctx context.Context) (map[string]appliedMigration, error) {
+	applied := make(map[string]appliedMigration)
+	if !m.db.Migrator().HasTable(migrationsTable) {
+		return applied, nil
+	}
 
-		// Read SQL file
-		sqlBytes, err := os.ReadFile(file)
-		if err != nil {
-			return fmt.Errorf("failed to read migration file %s: %w", file, err)
-		}
-
-		sql := string(sqlBytes)
-
-		// Register migration
-		RegisterMigration(Migration{
-			Version:     version,
-			Description: fmt.Sprintf("Migration from %s", file),
-			Up: func(db *gorm.DB) error {
-				return db.Exec(sql).Error
-			},
-			Down: func(db *gorm.DB) error {
-				// Try to find corresponding down file
-				downFile := filepath.Join(migrationsDir, version+".down.sql")
-				if _, err := os.Stat(downFile); err == nil {
-					downSQL, err := os.ReadFile(downFile)
-					if err != nil {
-						return err
-					}
-					return db.Exec(string(downSQL)).Error
-				}
-				return nil // No down migration, skip
-			},
-		})
+	query := "SELECT version, description, applied_at FROM " + migrationsTable
+	hasChecksum := m.db.Migrator().HasColumn(migrationsTable, "checksum")
+	if hasChecksum {
+		query = "SELECT version, description, applied_at, checksum FROM " + migrationsTable
 	}
 
-	return nil
+	rows, err := m.db.WithContext(WithPrimary(ctx)).Raw(query).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			record              appliedMigration
+			description, digest *string
+		)
+		dest := []interface{}{&record.Version, &description, &record.AppliedAt}
+		if hasChecksum {
+			dest = append(dest, &digest)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		if description != nil {
+			record.Description = *description
+		}
+		if digest != nil {
+			record.Checksum = *digest
+		}
+		applied[record.Version] = record
+	}
+	return applied, rows.Err()
 }
 
+// appliedVersions returns the applied versions in ascending version order
+func appliedVersions(applied map[string]appliedMigration) []string {
+	versions := make([]string, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
+	})
+	return versions
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Advisory lock taken while migrating. It is scoped to the current database because
+// lock names (MySQL) and keys (PostgreSQL) are server-wide.
+const (
+	mysqlMigrationLock    = "CONCAT('schema_migrations:', DATABASE())"
+	postgresMigrationLock = "hashtext('schema_migrations:' || current_database())"
+)
+
+// postgresLockPollInterval is the delay between attempts to take the PostgreSQL lock
+const postgresLockPollInterval = 500 * time.Millisecond
+
+// lock takes the migration advisory lock and returns a function releasing it.
+// SQLite serializes writers itself and runs unlocked.
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	dialect := m.db.Dialector.Name()
+	if dialect != "mysql" && dialect != "postgres" {
+		return func() {}, nil
+	}
+
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+
+	// Advisory locks belong to the session, so they are taken and released on one dedicated connection
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection for migration lock: %w", err)
+	}
+
+	var acquired bool
+	if dialect == "mysql" {
+		acquired, err = m.lockMySQL(ctx, conn)
+	} else {
+		acquired, err = m.lockPostgres(ctx, conn)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return nil, fmt.Errorf("timed out after %s waiting for the migration lock held by another process", m.opts.LockTimeout)
+	}
+
+	release := "SELECT RELEASE_LOCK(" + mysqlMigrationLock + ")"
+	if dialect == "postgres" {
+		release = "SELECT pg_advisory_unlock(" + postgresMigrationLock + ")"
+	}
+	return func() {
+		_, _ = conn.ExecContext(context.Background(), release)
+		conn.Close()
+	}, nil
+}
+
+// lockMySQL waits for GET_LOCK, which returns 1 once taken and 0 on timeout
+func (m *Migrator) lockMySQL(ctx context.Context, conn *sql.Conn) (bool, error) {
+	var acquired sql.NullInt64
+	timeout := int(m.opts.LockTimeout.Seconds())
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK("+mysqlMigrationLock+", ?)", timeout).Scan(&acquired); err != nil {
+		return false, err
+	}
+	return acquired.Valid && acquired.Int64 == 1, nil
+}
+
+// lockPostgres polls pg_try_advisory_lock until the lock timeout, as pg_advisory_lock has no timeout
+func (m *Migrator) lockPostgres(ctx context.Context, conn *sql.Conn) (bool, error) {
+	deadline := time.Now().Add(m.opts.LockTimeout)
+	for {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock("+postgresMigrationLock+")").Scan(&acquired); err != nil {
+			return false, err
+		}
+		if acquired || time.Now().After(deadline) {
+			return acquired, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(postgresLockPollInterval):
+		}
+	}
+}
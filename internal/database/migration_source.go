@@ -0,0 +1,256 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// migrationFilePattern matches <version>_<name>.up.sql and <version>_<name>.down.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_]+)\.(up|down)\.sql$`)
+
+// LoadMigrations reads the SQL migrations at the root of source, e.g. migrations.ForDriver(driver)
+// or os.DirFS(dir). Every version needs an .up.sql file; the .down.sql file is optional.
+// The dialect ("mysql", "postgres", "sqlite") selects the quoting and comment rules used to
+// split files into statements.
+func LoadMigrations(source fs.FS, dialect string) ([]Migration, error) {
+	syntax := syntaxOf(dialect)
+
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[string]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q (expected <version>_<name>.up.sql or .down.sql)", entry.Name())
+		}
+		version, name, direction := match[1], match[2], match[3]
+
+		content, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Description: name}
+			byVersion[version] = migration
+		}
+		if migration.Description != name {
+			return nil, fmt.Errorf("migration version %s is used by %q and %q", version, migration.Description, name)
+		}
+
+		statements := splitStatements(string(content), syntax)
+		if direction == "up" {
+			migration.UpSQL = statements
+			migration.Checksum = checksum(content)
+		} else {
+			migration.DownSQL = statements
+		}
+	}
+
+	loaded := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Checksum == "" {
+			return nil, fmt.Errorf("migration %s_%s has a .down.sql file but no .up.sql file", migration.Version, migration.Description)
+		}
+		loaded = append(loaded, *migration)
+	}
+	sortMigrations(loaded)
+	return loaded, nil
+}
+
+// sortMigrations orders migrations by numeric version, so "9" runs before "10"
+func sortMigrations(list []Migration) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return compareVersions(list[i].Version, list[j].Version) < 0
+	})
+}
+
+// compareVersions compares two numeric versions of any length
+func compareVersions(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// checksum returns the SHA-256 of a migration file, used to detect files changed after being applied
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// sqlSyntax holds the lexical rules that differ between dialects
+type sqlSyntax struct {
+	hashComments     bool // # starts a comment (MySQL); an operator in PostgreSQL
+	backslashEscapes bool // \' escapes a quote inside strings (MySQL)
+	dollarQuotes     bool // $$...$$ and $tag$...$tag$ strings, e.g. function bodies (PostgreSQL)
+}
+
+func syntaxOf(dialect string) sqlSyntax {
+	switch dialect {
+	case "mysql":
+		return sqlSyntax{hashComments: true, backslashEscapes: true}
+	case "postgres":
+		return sqlSyntax{dollarQuotes: true}
+	default:
+		return sqlSyntax{}
+	}
+}
+
+// dollarQuoteTag matches the opening tag of a PostgreSQL dollar-quoted string
+var dollarQuoteTag = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// splitStatements splits a SQL file into statements separated by semicolons.
+// Semicolons inside quotes, backticks and comments are ignored, and comment-only
+// statements are dropped. MySQL stored procedure bodies with inner semicolons are not supported;
+// PostgreSQL function bodies must be dollar-quoted.
+func splitStatements(sql string, syntax sqlSyntax) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		hasCode    bool // current contains something other than comments and whitespace
+	)
+	flush := func() {
+		if hasCode {
+			statements = append(statements, strings.TrimSpace(current.String()))
+		}
+		current.Reset()
+		hasCode = false
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := quoteEnd(sql, i, syntax.backslashEscapes)
+			current.WriteString(sql[i:end])
+			hasCode = true
+			i = end - 1
+		case c == '$' && syntax.dollarQuotes && dollarQuoteTag.MatchString(sql[i:]):
+			tag := dollarQuoteTag.FindString(sql[i:])
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				end = len(sql)
+			} else {
+				end = i + len(tag) + end + len(tag)
+			}
+			current.WriteString(sql[i:end])
+			hasCode = true
+			i = end - 1
+		case c == '-' && strings.HasPrefix(sql[i:], "--"), c == '#' && syntax.hashComments:
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			current.WriteString(sql[i : i+end])
+			i += end - 1
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i - 2
+			} else {
+				end += 2
+			}
+			current.WriteString(sql[i : i+2+end])
+			i += 2 + end - 1
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				hasCode = true
+			}
+		}
+	}
+	flush()
+	return statements
+}
+
+// quoteEnd returns the index after the quoted string starting at start.
+// Doubled quotes, and backslash escapes when enabled, do not end the string.
+func quoteEnd(sql string, start int, backslashEscapes bool) int {
+	quote := sql[start]
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if backslashEscapes && quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+// migrationNamePattern matches the characters allowed in migration names
+var migrationNamePattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// CreateMigrationFiles creates empty <timestamp>_<name>.up.sql and .down.sql files in dir
+// and returns their paths. The UTC timestamp (YYYYMMDDHHMMSS) keeps versions ordered.
+func CreateMigrationFiles(dir, name string) (string, string, error) {
+	return WriteMigrationFiles(dir, name,
+		"-- Write the schema change here\n",
+		"-- Write the statements that revert the .up.sql file here\n",
+	)
+}
+
+// WriteMigrationFiles creates <timestamp>_<name>.up.sql and .down.sql files with the given SQL
+// and returns their paths
+func WriteMigrationFiles(dir, name, upSQL, downSQL string) (string, string, error) {
+	name = strings.Trim(migrationNamePattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", fmt.Errorf("migration name must contain letters or digits")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+
+	version := time.Now().UTC().Format("20060102150405")
+	base := filepath.Join(dir, version+"_"+name)
+	upFile, downFile := base+".up.sql", base+".down.sql"
+
+	header := fmt.Sprintf("-- Migration: %s_%s\n", version, name)
+	if err := writeNewFile(upFile, header+upSQL); err != nil {
+		return "", "", err
+	}
+	if err := writeNewFile(downFile, header+downSQL); err != nil {
+		return "", "", err
+	}
+	return upFile, downFile, nil
+}
+
+// writeNewFile writes content to path, failing if the file already exists
+func writeNewFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create migration file: %w", err)
+	}
+	defer f.Close()
+	_, err = f.WriteString(content)
+	return err
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"llm-aggregator/internal/entity"
+	"llm-aggregator/internal/logger"
+)
+
+// PurgeBatchSize is the number of rows hard-deleted per statement, keeping locks short
+const PurgeBatchSize = 500
+
+// PurgeActor is the actor of the purges recorded in audit_logs
+const PurgeActor = SystemActor + ":soft-delete-purge"
+
+// RunPurgeJob hard-deletes the users and orders soft-deleted more than retentionDays ago, on
+// start and then daily, until ctx is cancelled
+func RunPurgeJob(ctx context.Context, db *gorm.DB, retentionDays int) {
+	ticker := time.NewTicker(24 * time.Hour) // Run once per day
+	defer ticker.Stop()
+
+	for {
+		purge(ctx, db, retentionDays)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge runs PurgeSoftDeleted and logs the outcome
+func purge(ctx context.Context, db *gorm.DB, retentionDays int) {
+	log := logger.GetLogger()
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+
+	orders, users, err := PurgeSoftDeleted(ctx, db, cutoff)
+	if err != nil {
+		log.Error("Failed to purge soft-deleted rows",
+			zap.Time("cutoff", cutoff),
+			zap.Int64("orders", orders),
+			zap.Int64("users", users),
+			zap.Error(err),
+		)
+		return
+	}
+	if orders > 0 || users > 0 {
+		log.Info("Purged soft-deleted rows",
+			zap.Time("cutoff", cutoff),
+			zap.Int64("orders", orders),
+			zap.Int64("users", users),
+		)
+	}
+}
+
+// PurgeSoftDeleted hard-deletes the orders, then the users, soft-deleted before cutoff.
+// Users that still have orders (restored, or deleted after cutoff) are kept until their orders
+// are gone, so no order is left without its user.
+func PurgeSoftDeleted(ctx context.Context, db *gorm.DB, cutoff time.Time) (orders, users int64, err error) {
+	db = db.WithContext(WithActor(WithPrimary(ctx), PurgeActor)).Unscoped().Session(&gorm.Session{})
+
+	orders, err = purgeBatches(ctx, db, &entity.Order{}, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where(entity.OrderColumn.DeletedAt+" < ?", cutoff)
+	})
+	if err != nil {
+		return orders, 0, err
+	}
+
+	users, err = purgeBatches(ctx, db, &entity.User{}, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where(entity.Column.DeletedAt+" < ?", cutoff).
+			Where("NOT EXISTS (SELECT 1 FROM " + entity.OrderTableName + " WHERE " +
+				entity.OrderTableName + "." + entity.OrderColumn.UserID + " = " +
+				entity.UserTableName + "." + entity.Column.ID + ")")
+	})
+	return orders, users, err
+}
+
+// purgeBatches deletes the rows of model matched by scope, PurgeBatchSize at a time.
+// The delete repeats scope, so a row restored after it was selected is kept.
+func purgeBatches(ctx context.Context, db *gorm.DB, model interface{}, scope func(tx *gorm.DB) *gorm.DB) (int64, error) {
+	var total int64
+	for {
+		var ids []string
+		if err := scope(db.Model(model)).Limit(PurgeBatchSize).Pluck("id", &ids).Error; err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		result := scope(db.Where("id IN ?", ids)).Delete(model)
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+
+		if len(ids) < PurgeBatchSize {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
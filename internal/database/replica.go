@@ -0,0 +1,419 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+
+	"llm-aggregator/internal/config"
+	"llm-aggregator/internal/logger"
+)
+
+// replicaSetName is the name of the ReplicaSet GORM plugin
+const replicaSetName = "llm-aggregator:replicas"
+
+type (
+	primaryKey      struct{}
+	writeTrackerKey struct{}
+)
+
+// WithPrimary returns a context whose queries read from the primary, e.g. to read a row
+// right before updating it
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// WithWriteTracking returns a context that records writes made with it, so reads following
+// a write go to the primary (read-your-writes). middleware.DatabaseSession sets it per request.
+func WithWriteTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, writeTrackerKey{}, &writeTracker{})
+}
+
+// writeTracker holds the time of the last write made with a context
+type writeTracker struct {
+	lastWrite atomic.Int64 // Unix nanoseconds, 0 before the first write
+}
+
+// ReplicaStatus is the result of the last health check of a replica
+type ReplicaStatus struct {
+	Address    string    `json:"address"`
+	Healthy    bool      `json:"healthy"`
+	LagSeconds float64   `json:"lag_seconds"`
+	Error      string    `json:"error,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+type replica struct {
+	address string
+	db      *sql.DB
+
+	mu     sync.RWMutex
+	status ReplicaStatus
+}
+
+func (r *replica) getStatus() ReplicaStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.status
+}
+
+func (r *replica) setStatus(status ReplicaStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+// ReplicaSet routes reads to read replicas. It is a GORM plugin working with dbresolver:
+//   - dbresolver sends writes, locking reads and transactions to the primary;
+//   - as the dbresolver policy, ReplicaSet picks a healthy replica, or falls back to the primary
+//     when every replica is down or lags more than the allowed maximum;
+//   - its callbacks move reads back to the primary when the context asks for it (WithPrimary),
+//     or shortly after a write made with the same context (WithWriteTracking).
+type ReplicaSet struct {
+	dialect  string
+	primary  gorm.ConnPool
+	replicas []*replica
+	byPool   map[gorm.ConnPool]*replica
+	maxLag   time.Duration
+	sticky   time.Duration
+	interval time.Duration
+}
+
+// setupReplicas opens the configured replicas and registers read routing on db.
+// Replicas that are down at startup are marked unhealthy instead of failing.
+func setupReplicas(db *gorm.DB, cfg config.DatabaseConfig) error {
+	set := &ReplicaSet{
+		dialect:  db.Dialector.Name(),
+		byPool:   make(map[gorm.ConnPool]*replica),
+		maxLag:   time.Duration(cfg.Replicas.MaxLagSeconds) * time.Second,
+		sticky:   time.Duration(cfg.Replicas.StickySeconds) * time.Second,
+		interval: time.Duration(cfg.Replicas.CheckIntervalSeconds) * time.Second,
+	}
+
+	dialectors := make([]gorm.Dialector, 0, len(cfg.Replicas.Hosts)+1)
+	for _, host := range cfg.Replicas.Hosts {
+		replicaCfg := cfg.ForReplica(host)
+		replicaDB, err := gorm.Open(replicaDialector(replicaCfg), &gorm.Config{DisableAutomaticPing: true, Logger: db.Logger})
+		if err != nil {
+			return fmt.Errorf("failed to open replica %s: %w", host, err)
+		}
+		sqlDB, err := replicaDB.DB()
+		if err != nil {
+			return fmt.Errorf("failed to get sql.DB of replica %s: %w", host, err)
+		}
+
+		configurePool(sqlDB, cfg)
+
+		r := &replica{address: host, db: sqlDB}
+		set.replicas = append(set.replicas, r)
+		set.byPool[sqlDB] = r
+		dialectors = append(dialectors, connDialector(cfg.Driver, sqlDB))
+	}
+
+	// The primary is the last candidate for reads, used when no replica is healthy
+	primary, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+	set.primary = primary
+	dialectors = append(dialectors, connDialector(cfg.Driver, primary))
+
+	set.checkAll(context.Background())
+
+	// dbresolver opens every dialector again; the primary is already pinged and replicas may be down
+	db.Config.DisableAutomaticPing = true
+	if err := db.Use(set); err != nil {
+		return err
+	}
+	return db.Use(dbresolver.Register(dbresolver.Config{Replicas: dialectors, Policy: set}))
+}
+
+// replicaDialector opens a replica without querying it, so a replica that is down does not fail startup
+func replicaDialector(cfg config.DatabaseConfig) gorm.Dialector {
+	switch cfg.Driver {
+	case config.DriverMySQL:
+		return mysql.New(mysql.Config{DSN: cfg.DSN(), SkipInitializeWithVersion: true})
+	case config.DriverPostgres:
+		return postgres.New(postgres.Config{DSN: cfg.DSN()})
+	default:
+		return sqlite.Open(cfg.DSN())
+	}
+}
+
+// connDialector returns a dialector reusing an open connection pool
+func connDialector(driver string, conn gorm.ConnPool) gorm.Dialector {
+	switch driver {
+	case config.DriverMySQL:
+		return mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true})
+	case config.DriverPostgres:
+		return postgres.New(postgres.Config{Conn: conn})
+	default:
+		return &sqlite.Dialector{Conn: conn}
+	}
+}
+
+// Name implements gorm.Plugin
+func (s *ReplicaSet) Name() string {
+	return replicaSetName
+}
+
+// Initialize implements gorm.Plugin. The routing callbacks run after dbresolver picked a connection.
+func (s *ReplicaSet) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().After("gorm:db_resolver").Before("gorm:query").Register("replicas:route_read", s.routeRead); err != nil {
+		return err
+	}
+	if err := callbacks.Row().After("gorm:db_resolver").Before("gorm:row").Register("replicas:route_read", s.routeRead); err != nil {
+		return err
+	}
+	if err := callbacks.Raw().After("gorm:db_resolver").Before("gorm:raw").Register("replicas:route_read", s.routeRead); err != nil {
+		return err
+	}
+
+	if err := callbacks.Create().After("gorm:create").Register("replicas:track_write", trackWrite); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("replicas:track_write", trackWrite); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:delete").Register("replicas:track_write", trackWrite); err != nil {
+		return err
+	}
+	return callbacks.Raw().After("gorm:raw").Register("replicas:track_write", trackWrite)
+}
+
+// routeRead moves a read from a replica to the primary when the context requires it
+func (s *ReplicaSet) routeRead(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if _, onReplica := s.byPool[db.Statement.ConnPool]; !onReplica || ctx == nil {
+		return
+	}
+	if forced, _ := ctx.Value(primaryKey{}).(bool); forced {
+		db.Statement.ConnPool = s.primary
+		return
+	}
+	if tracker, ok := ctx.Value(writeTrackerKey{}).(*writeTracker); ok {
+		if last := tracker.lastWrite.Load(); last != 0 && time.Since(time.Unix(0, last)) < s.sticky {
+			db.Statement.ConnPool = s.primary
+		}
+	}
+}
+
+// trackWrite records a successful write in the context's write tracker
+func trackWrite(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if db.Error != nil || ctx == nil {
+		return
+	}
+	tracker, ok := ctx.Value(writeTrackerKey{}).(*writeTracker)
+	if !ok {
+		return
+	}
+	if sql := strings.TrimSpace(db.Statement.SQL.String()); len(sql) >= 6 && strings.EqualFold(sql[:6], "select") {
+		return
+	}
+	tracker.lastWrite.Store(time.Now().UnixNano())
+}
+
+// Resolve implements dbresolver.Policy: a random healthy replica, or the primary (the last pool)
+func (s *ReplicaSet) Resolve(pools []gorm.ConnPool) gorm.ConnPool {
+	healthy := make([]gorm.ConnPool, 0, len(pools))
+	for _, pool := range pools {
+		if r, ok := s.byPool[pool]; ok && r.getStatus().Healthy {
+			healthy = append(healthy, pool)
+		}
+	}
+	if len(healthy) == 0 {
+		return pools[len(pools)-1]
+	}
+	return healthy[rand.Intn(len(healthy))]
+}
+
+// Statuses returns the result of the last health check of every replica
+func (s *ReplicaSet) Statuses() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, len(s.replicas))
+	for i, r := range s.replicas {
+		statuses[i] = r.getStatus()
+	}
+	return statuses
+}
+
+// Monitor checks replica health and lag every check interval until ctx is done
+func (s *ReplicaSet) Monitor(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkAll(ctx)
+		}
+	}
+}
+
+func (s *ReplicaSet) checkAll(ctx context.Context) {
+	for _, r := range s.replicas {
+		previous := r.getStatus()
+		status := s.check(ctx, r)
+		r.setStatus(status)
+
+		// Log transitions only, not every check
+		if status.Healthy != previous.Healthy || previous.CheckedAt.IsZero() {
+			fields := []zap.Field{zap.String("replica", r.address), zap.Float64("lag_seconds", status.LagSeconds)}
+			if status.Healthy {
+				logger.GetLogger().Info("Read replica is healthy", fields...)
+			} else {
+				logger.GetLogger().Warn("Read replica is unhealthy, reads go to other replicas or the primary",
+					append(fields, zap.String("error", status.Error))...)
+			}
+		}
+	}
+}
+
+// check pings a replica and measures its replication lag
+func (s *ReplicaSet) check(ctx context.Context, r *replica) ReplicaStatus {
+	status := ReplicaStatus{Address: r.address, CheckedAt: time.Now()}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := r.db.PingContext(ctx); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	lag, err := replicationLag(ctx, r.db, s.dialect)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.LagSeconds = lag.Seconds()
+	if lag > s.maxLag {
+		status.Error = fmt.Sprintf("replication lag %s exceeds %s", lag.Round(time.Second), s.maxLag)
+		return status
+	}
+	status.Healthy = true
+	return status
+}
+
+// replicationLag returns how far a replica is behind its primary
+func replicationLag(ctx context.Context, db *sql.DB, dialect string) (time.Duration, error) {
+	switch dialect {
+	case "mysql":
+		return mysqlReplicationLag(ctx, db)
+	case "postgres":
+		// An idle primary sends no transactions to replay; a replica that replayed everything is not behind
+		var seconds float64
+		err := db.QueryRowContext(ctx, `SELECT CASE
+			WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END`).Scan(&seconds)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read replication lag: %w", err)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	default:
+		return 0, nil
+	}
+}
+
+// mysqlReplicationLag reads Seconds_Behind_Source (MySQL 8.0.22+) or Seconds_Behind_Master
+func mysqlReplicationLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		if rows, err = db.QueryContext(ctx, "SHOW SLAVE STATUS"); err != nil {
+			return 0, fmt.Errorf("failed to read replica status: %w", err)
+		}
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		// Not configured as a replica (e.g. a read-only copy); nothing to lag behind
+		return 0, rows.Err()
+	}
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+		if values[i] == nil {
+			return 0, errors.New("replication is not running")
+		}
+		seconds, err := strconv.Atoi(string(values[i]))
+		if err != nil {
+			return 0, fmt.Errorf("invalid replication lag %q", values[i])
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, nil
+}
+
+// replicaSet returns the replica set registered on db, or nil without replicas
+func replicaSet(db *gorm.DB) *ReplicaSet {
+	if db == nil {
+		return nil
+	}
+	set, _ := db.Config.Plugins[replicaSetName].(*ReplicaSet)
+	return set
+}
+
+// ReplicaStatuses returns the health of the read replicas, or nil when none are configured
+func ReplicaStatuses(db *gorm.DB) []ReplicaStatus {
+	if set := replicaSet(db); set != nil {
+		return set.Statuses()
+	}
+	return nil
+}
+
+// MonitorReplicas checks the read replicas until ctx is done. It returns at once without replicas.
+func MonitorReplicas(ctx context.Context, db *gorm.DB) {
+	if set := replicaSet(db); set != nil {
+		set.Monitor(ctx)
+	}
+}
+
+// Close closes the connection pools of the primary and the read replicas
+func Close(db *gorm.DB) error {
+	var errs []error
+	if set := replicaSet(db); set != nil {
+		for _, r := range set.replicas {
+			if err := r.db.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("replica %s: %w", r.address, err))
+			}
+		}
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	if err := sqlDB.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
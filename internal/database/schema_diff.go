@@ -0,0 +1,526 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// SchemaChangeKind is the kind of difference between a model and the live schema
+type SchemaChangeKind string
+
+const (
+	TableMissing      SchemaChangeKind = "table_missing"      // Model table does not exist
+	ColumnMissing     SchemaChangeKind = "column_missing"     // Model field has no column
+	ColumnExtra       SchemaChangeKind = "column_extra"       // Column has no model field
+	ColumnType        SchemaChangeKind = "column_type"        // Column type differs from the field type
+	ColumnNullability SchemaChangeKind = "column_nullability" // Column NULL/NOT NULL differs from the field
+	IndexMissing      SchemaChangeKind = "index_missing"      // Model index does not exist
+	IndexExtra        SchemaChangeKind = "index_extra"        // Index is not declared on the model
+	IndexMismatch     SchemaChangeKind = "index_mismatch"     // Index columns or uniqueness differ
+)
+
+// SchemaChange is one difference between a model and the live schema.
+// UpSQL turns the live schema into the model; DownSQL reverts it.
+type SchemaChange struct {
+	Table    string
+	Kind     SchemaChangeKind
+	Name     string // Column or index name; empty for TableMissing
+	Expected string // As declared on the model
+	Actual   string // As found in the database
+	UpSQL    []string
+	DownSQL  []string
+}
+
+// SchemaDiff is the result of comparing the models with the live schema
+type SchemaDiff struct {
+	Changes []SchemaChange
+}
+
+// Empty reports whether the live schema matches the models
+func (d *SchemaDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// DraftMigration returns the contents of .up.sql and .down.sql files applying the diff.
+// The statements are generated by GORM for the connected dialect and should be reviewed
+// before use, e.g. type changes may need a data conversion.
+func (d *SchemaDiff) DraftMigration() (string, string) {
+	var up, down strings.Builder
+	up.WriteString("-- Draft generated by schema diff, review before applying\n")
+	down.WriteString("-- Draft generated by schema diff, review before applying\n")
+
+	for _, change := range d.Changes {
+		fmt.Fprintf(&up, "\n-- %s\n", change.describe())
+		writeStatements(&up, change.UpSQL)
+	}
+	// Revert in reverse order
+	for i := len(d.Changes) - 1; i >= 0; i-- {
+		change := d.Changes[i]
+		fmt.Fprintf(&down, "\n-- %s\n", change.describe())
+		writeStatements(&down, change.DownSQL)
+	}
+	return up.String(), down.String()
+}
+
+// writeStatements writes one statement per line; comments (changes needing manual SQL) are kept as is
+func writeStatements(b *strings.Builder, statements []string) {
+	for _, statement := range statements {
+		if strings.HasPrefix(statement, "--") {
+			b.WriteString(statement + "\n")
+		} else {
+			b.WriteString(statement + ";\n")
+		}
+	}
+}
+
+func (c SchemaChange) describe() string {
+	text := fmt.Sprintf("%s: %s", c.Table, c.Kind)
+	if c.Name != "" {
+		text += " " + c.Name
+	}
+	if c.Expected != "" || c.Actual != "" {
+		text += fmt.Sprintf(" (model: %s, database: %s)", orDash(c.Expected), orDash(c.Actual))
+	}
+	return text
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// DiffSchema compares the models (default: the entities passed to AutoMigrate) with the live schema:
+// tables, columns, column types, nullability and indexes
+func DiffSchema(db *gorm.DB, models ...interface{}) (*SchemaDiff, error) {
+	if len(models) == 0 {
+		models = Models()
+	}
+
+	// Compare against the primary; a lagging replica may not have the latest schema yet
+	db = db.WithContext(WithPrimary(context.Background()))
+	d := &schemaDiffer{db: db, sql: &sqlRecorder{}}
+	d.dryRun = db.Session(&gorm.Session{DryRun: true, Logger: d.sql})
+
+	diff := &SchemaDiff{}
+	for _, model := range models {
+		changes, err := d.diffModel(model)
+		if err != nil {
+			return nil, err
+		}
+		diff.Changes = append(diff.Changes, changes...)
+	}
+	return diff, nil
+}
+
+// schemaDiffer compares one model at a time. Draft SQL is produced by running GORM's migrator
+// in a dry-run session and recording the statements it would execute.
+type schemaDiffer struct {
+	db     *gorm.DB
+	dryRun *gorm.DB
+	sql    *sqlRecorder
+}
+
+func (d *schemaDiffer) diffModel(model interface{}) ([]SchemaChange, error) {
+	stmt := &gorm.Statement{DB: d.db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, fmt.Errorf("failed to parse model %T: %w", model, err)
+	}
+	table := stmt.Schema.Table
+
+	if !d.db.Migrator().HasTable(model) {
+		up, err := d.record(func(m gorm.Migrator) error { return m.CreateTable(model) })
+		if err != nil {
+			return nil, err
+		}
+		down, err := d.record(func(m gorm.Migrator) error { return m.DropTable(model) })
+		if err != nil {
+			return nil, err
+		}
+		return []SchemaChange{{Table: table, Kind: TableMissing, UpSQL: up, DownSQL: down}}, nil
+	}
+
+	columns, err := d.columnChanges(model, stmt.Schema)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := d.indexChanges(model, stmt.Schema)
+	if err != nil {
+		return nil, err
+	}
+	return append(columns, indexes...), nil
+}
+
+func (d *schemaDiffer) columnChanges(model interface{}, s *schema.Schema) ([]SchemaChange, error) {
+	columnTypes, err := d.db.Migrator().ColumnTypes(model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", s.Table, err)
+	}
+	live := make(map[string]gorm.ColumnType, len(columnTypes))
+	for _, column := range columnTypes {
+		live[column.Name()] = column
+	}
+
+	var changes []SchemaChange
+	for _, field := range s.Fields {
+		if field.DBName == "" || field.IgnoreMigration {
+			continue
+		}
+		expectedType := d.dataTypeOf(field)
+
+		column, ok := live[field.DBName]
+		if !ok {
+			change := SchemaChange{Table: s.Table, Kind: ColumnMissing, Name: field.DBName, Expected: expectedType}
+			if change.UpSQL, err = d.record(func(m gorm.Migrator) error { return m.AddColumn(model, field.Name) }); err != nil {
+				return nil, err
+			}
+			if change.DownSQL, err = d.record(func(m gorm.Migrator) error { return m.DropColumn(model, field.DBName) }); err != nil {
+				return nil, err
+			}
+			changes = append(changes, change)
+			continue
+		}
+		delete(live, field.DBName)
+
+		actualType := liveColumnType(column)
+		kind, expected, actual := SchemaChangeKind(""), "", ""
+		if !columnTypesMatch(expectedType, actualType) {
+			kind, expected, actual = ColumnType, expectedType, actualType
+		} else if nullable, ok := column.Nullable(); ok && !field.PrimaryKey && nullable == field.NotNull {
+			kind, expected, actual = ColumnNullability, nullability(!field.NotNull), nullability(nullable)
+		}
+		if kind == "" {
+			continue
+		}
+
+		change := SchemaChange{Table: s.Table, Kind: kind, Name: field.DBName, Expected: expected, Actual: actual}
+		if change.UpSQL, err = d.record(func(m gorm.Migrator) error { return m.AlterColumn(model, field.Name) }); err != nil {
+			return nil, err
+		}
+		change.DownSQL = []string{fmt.Sprintf("-- restore column %s to %s %s", field.DBName, actualType, nullability(isNullable(column)))}
+		changes = append(changes, change)
+	}
+
+	// Columns left over have no model field, in table order
+	for _, column := range columnTypes {
+		if _, ok := live[column.Name()]; !ok {
+			continue
+		}
+		definition := liveColumnType(column)
+		if !isNullable(column) {
+			definition += " NOT NULL"
+		}
+
+		change := SchemaChange{Table: s.Table, Kind: ColumnExtra, Name: column.Name(), Actual: definition}
+		if change.UpSQL, err = d.record(func(m gorm.Migrator) error { return m.DropColumn(model, column.Name()) }); err != nil {
+			return nil, err
+		}
+		change.DownSQL, err = d.exec("ALTER TABLE ? ADD ? ?",
+			clause.Table{Name: s.Table}, clause.Column{Name: column.Name()}, clause.Expr{SQL: definition})
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// liveIndex is an index found in the database
+type liveIndex struct {
+	name    string
+	columns []string
+	unique  bool
+}
+
+func (i liveIndex) String() string {
+	return describeIndex(i.columns, i.unique)
+}
+
+func (d *schemaDiffer) indexChanges(model interface{}, s *schema.Schema) ([]SchemaChange, error) {
+	indexes, err := d.db.Migrator().GetIndexes(model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indexes of %s: %w", s.Table, err)
+	}
+
+	live := make(map[string]liveIndex)
+	for _, index := range indexes {
+		if primary, _ := index.PrimaryKey(); primary {
+			continue
+		}
+		unique, _ := index.Unique()
+		live[index.Name()] = liveIndex{name: index.Name(), columns: index.Columns(), unique: unique}
+	}
+
+	// Columns tagged `unique` get a unique index named by the database
+	uniqueFields := make(map[string]bool)
+	for _, field := range s.Fields {
+		if field.Unique {
+			uniqueFields[field.DBName] = true
+		}
+	}
+
+	var changes []SchemaChange
+	modelIndexes := s.ParseIndexes()
+	names := make([]string, 0, len(modelIndexes))
+	for name := range modelIndexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		index := modelIndexes[name]
+		columns := make([]string, len(index.Fields))
+		for i, field := range index.Fields {
+			columns[i] = field.DBName
+		}
+		unique := index.Class == "UNIQUE"
+		expected := describeIndex(columns, unique)
+
+		actual, ok := live[name]
+		if !ok {
+			change := SchemaChange{Table: s.Table, Kind: IndexMissing, Name: name, Expected: expected}
+			if change.UpSQL, err = d.record(func(m gorm.Migrator) error { return m.CreateIndex(model, name) }); err != nil {
+				return nil, err
+			}
+			if change.DownSQL, err = d.record(func(m gorm.Migrator) error { return m.DropIndex(model, name) }); err != nil {
+				return nil, err
+			}
+			changes = append(changes, change)
+			continue
+		}
+		delete(live, name)
+
+		if actual.unique == unique && reflect.DeepEqual(actual.columns, columns) {
+			continue
+		}
+		change := SchemaChange{Table: s.Table, Kind: IndexMismatch, Name: name, Expected: expected, Actual: actual.String()}
+		if change.UpSQL, err = d.record(func(m gorm.Migrator) error {
+			if err := m.DropIndex(model, name); err != nil {
+				return err
+			}
+			return m.CreateIndex(model, name)
+		}); err != nil {
+			return nil, err
+		}
+		if change.DownSQL, err = d.record(func(m gorm.Migrator) error { return m.DropIndex(model, name) }); err != nil {
+			return nil, err
+		}
+		restore, err := d.createIndexSQL(s.Table, actual)
+		if err != nil {
+			return nil, err
+		}
+		change.DownSQL = append(change.DownSQL, restore...)
+		changes = append(changes, change)
+	}
+
+	extra := make([]string, 0, len(live))
+	for name, index := range live {
+		if index.unique && len(index.columns) == 1 && uniqueFields[index.columns[0]] {
+			continue
+		}
+		extra = append(extra, name)
+	}
+	sort.Strings(extra)
+
+	for _, name := range extra {
+		index := live[name]
+		change := SchemaChange{Table: s.Table, Kind: IndexExtra, Name: name, Actual: index.String()}
+		if change.UpSQL, err = d.record(func(m gorm.Migrator) error { return m.DropIndex(model, name) }); err != nil {
+			return nil, err
+		}
+		if change.DownSQL, err = d.createIndexSQL(s.Table, index); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// createIndexSQL returns the statement re-creating an index found in the database
+func (d *schemaDiffer) createIndexSQL(table string, index liveIndex) ([]string, error) {
+	columns := make([]clause.Column, len(index.columns))
+	for i, column := range index.columns {
+		columns[i] = clause.Column{Name: column}
+	}
+	statement := "CREATE INDEX ? ON ? ?"
+	if index.unique {
+		statement = "CREATE UNIQUE INDEX ? ON ? ?"
+	}
+	return d.exec(statement, clause.Column{Name: index.name}, clause.Table{Name: table}, columns)
+}
+
+// record runs fn against the dry-run migrator and returns the statements it would execute.
+// Queries the migrator issues to inspect the schema are left out. Migrators that need to read
+// the schema to build a statement (e.g. SQLite rebuilding a table) cannot run dry; a comment
+// asking for hand-written SQL is returned instead. Any other panic is not recovered.
+func (d *schemaDiffer) record(fn func(gorm.Migrator) error) (statements []string, err error) {
+	d.sql.statements = nil
+	defer func() {
+		if r := recover(); r != nil {
+			if !isDryRunReadPanic(r) {
+				panic(r)
+			}
+			statements, err = []string{"-- write this change by hand, the " + d.db.Dialector.Name() + " migrator cannot generate it without executing it"}, nil
+		}
+	}()
+
+	if err := fn(d.dryRun.Migrator()); err != nil {
+		return nil, fmt.Errorf("failed to generate migration SQL: %w", err)
+	}
+	for _, statement := range d.sql.statements {
+		if !inspectionQuery.MatchString(statement) {
+			statements = append(statements, statement)
+		}
+	}
+	return statements, nil
+}
+
+// isDryRunReadPanic reports whether the panic being recovered is a migrator reading the result of
+// a query in a dry run: the query does not run, so Row and Rows are nil and their methods panic.
+// It must be called from the deferred function that recovered r.
+func isDryRunReadPanic(r interface{}) bool {
+	if _, ok := r.(runtime.Error); !ok {
+		return false
+	}
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(0, pcs)])
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, "database/sql.(*Row).") || strings.HasPrefix(frame.Function, "database/sql.(*Rows).") {
+			return true
+		}
+		if !more {
+			return false
+		}
+	}
+}
+
+// inspectionQuery matches statements migrators run to inspect or configure the session
+var inspectionQuery = regexp.MustCompile(`(?i)^(SELECT|PRAGMA|SHOW|SET)\b`)
+
+// exec records a hand-written statement, quoted for the connected dialect
+func (d *schemaDiffer) exec(sql string, values ...interface{}) ([]string, error) {
+	return d.record(func(gorm.Migrator) error {
+		return d.dryRun.Exec(sql, values...).Error
+	})
+}
+
+// dataTypeOf returns the column type GORM would create for a field
+func (d *schemaDiffer) dataTypeOf(field *schema.Field) string {
+	value := reflect.New(field.IndirectFieldType).Interface()
+	if typer, ok := value.(migrator.GormDataTypeInterface); ok {
+		if dataType := typer.GormDBDataType(d.db, field); dataType != "" {
+			return dataType
+		}
+	}
+	return d.db.Dialector.DataTypeOf(field)
+}
+
+func liveColumnType(column gorm.ColumnType) string {
+	if columnType, ok := column.ColumnType(); ok && columnType != "" {
+		return columnType
+	}
+	return column.DatabaseTypeName()
+}
+
+func isNullable(column gorm.ColumnType) bool {
+	nullable, ok := column.Nullable()
+	return !ok || nullable
+}
+
+func nullability(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
+
+func describeIndex(columns []string, unique bool) string {
+	text := "(" + strings.Join(columns, ", ") + ")"
+	if unique {
+		text = "UNIQUE " + text
+	}
+	return text
+}
+
+var (
+	// intDisplayWidth matches the display width MySQL 5.7 reports for integer types, e.g. int(11)
+	intDisplayWidth = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+	// postgresTypeNames maps the type names PostgreSQL reports (format_type) to the names GORM uses
+	postgresTypeNames = strings.NewReplacer(
+		"character varying", "varchar",
+		"character", "char",
+		"numeric", "decimal",
+		"timestamp with time zone", "timestamptz",
+		"timestamp without time zone", "timestamp",
+		"double precision", "double",
+		", ", ",",
+	)
+	// postgresTimestampPrecision matches "timestamp(3) with time zone"
+	postgresTimestampPrecision = regexp.MustCompile(`^timestamp(\(\d+\)) with time zone$`)
+	// columnTypeAliases maps spellings of the same type to one form
+	columnTypeAliases = map[string]string{
+		"integer":    "int",
+		"int4":       "int",
+		"int8":       "bigint",
+		"int2":       "smallint",
+		"bool":       "tinyint(1)",
+		"boolean":    "tinyint(1)",
+		"tinyint(1)": "tinyint(1)", // MySQL's boolean, keep its display width
+	}
+)
+
+// columnTypesMatch compares a model type with a live column type. Some drivers report truncated
+// types, e.g. the SQLite driver turns decimal(10,2) into "decimal(10"; only the base types are compared then.
+func columnTypesMatch(expected, actual string) bool {
+	expected, actual = normalizeColumnType(expected), normalizeColumnType(actual)
+	if strings.Count(actual, "(") != strings.Count(actual, ")") {
+		return baseColumnType(expected) == baseColumnType(actual)
+	}
+	return expected == actual
+}
+
+func baseColumnType(columnType string) string {
+	if i := strings.IndexByte(columnType, '('); i >= 0 {
+		return columnType[:i]
+	}
+	return columnType
+}
+
+// normalizeColumnType makes type names comparable across the model and the database
+func normalizeColumnType(columnType string) string {
+	columnType = strings.Join(strings.Fields(strings.ToLower(columnType)), " ")
+	columnType = postgresTimestampPrecision.ReplaceAllString(columnType, "timestamptz$1")
+	columnType = postgresTypeNames.Replace(columnType)
+	if alias, ok := columnTypeAliases[columnType]; ok {
+		return alias
+	}
+	return intDisplayWidth.ReplaceAllString(columnType, "$1")
+}
+
+// sqlRecorder is a GORM logger recording the SQL of every executed statement
+type sqlRecorder struct {
+	statements []string
+}
+
+func (r *sqlRecorder) LogMode(gormlogger.LogLevel) gormlogger.Interface { return r }
+func (r *sqlRecorder) Info(context.Context, string, ...interface{})     {}
+func (r *sqlRecorder) Warn(context.Context, string, ...interface{})     {}
+func (r *sqlRecorder) Error(context.Context, string, ...interface{})    {}
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, strings.TrimSuffix(strings.TrimSpace(sql), ";"))
+}
@@ -0,0 +1,62 @@
+package entity
+
+import "time"
+
+// Auditable is implemented by the entities whose creates, updates and deletes are recorded
+// in audit_logs (see database.auditPlugin)
+type Auditable interface {
+	// AuditEntity returns the entity type of the audit entries, e.g. "order"
+	AuditEntity() string
+}
+
+// AuditLog is one recorded change of an Auditable entity
+type AuditLog struct {
+	ID         string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	EntityType string    `gorm:"type:varchar(50);not null;index:idx_audit_logs_entity,priority:1" json:"entityType"`
+	EntityID   string    `gorm:"type:varchar(36);not null;index:idx_audit_logs_entity,priority:2" json:"entityId"`
+	Action     string    `gorm:"type:varchar(20);not null" json:"action"` // create, update, delete, restore, purge
+	Actor      string    `gorm:"type:varchar(255);not null" json:"actor"` // e.g. cert:<CN>, user:<name>, ip:<address>, system:<job>
+	RequestID  string    `gorm:"type:varchar(64)" json:"requestId"`       // Empty outside HTTP requests
+	OldValues  string    `gorm:"type:text" json:"oldValues"`              // JSON object of the changed columns before the change
+	NewValues  string    `gorm:"type:text" json:"newValues"`              // JSON object of the changed columns after the change
+	CreatedAt  time.Time `gorm:"autoCreateTime;index:idx_audit_logs_entity,priority:3" json:"createdAt"`
+}
+
+// AuditLogColumn contains all database column names for AuditLog entity
+var AuditLogColumn = struct {
+	ID         string
+	EntityType string
+	EntityID   string
+	Action     string
+	Actor      string
+	RequestID  string
+	OldValues  string
+	NewValues  string
+	CreatedAt  string
+}{
+	ID:         "id",
+	EntityType: "entity_type",
+	EntityID:   "entity_id",
+	Action:     "action",
+	Actor:      "actor",
+	RequestID:  "request_id",
+	OldValues:  "old_values",
+	NewValues:  "new_values",
+	CreatedAt:  "created_at",
+}
+
+// AuditLogTableName is the table name for AuditLog entity
+const AuditLogTableName = "audit_logs"
+
+// Audit actions
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"  // Soft delete
+	AuditActionRestore = "restore" // Soft delete undone
+	AuditActionPurge   = "purge"   // Hard delete
+)
+
+func (AuditLog) TableName() string {
+	return AuditLogTableName
+}
@@ -2,17 +2,22 @@ package entity
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Order struct {
-	ID          string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	ID          string    `gorm:"primaryKey;type:varchar(36);index:idx_orders_created_at_id,priority:2" json:"id"`
 	UserID      string    `gorm:"type:varchar(36);not null;index" json:"userId"`
 	ProductName string    `gorm:"type:varchar(255);not null" json:"productName"`
 	Quantity    int       `gorm:"type:int;not null;default:1" json:"quantity"`
 	Amount      float64   `gorm:"type:decimal(10,2);not null" json:"amount"`
-	Status      int       `gorm:"type:int;default:1" json:"status"` // 1: pending, 2: completed, 3: cancelled
-	CreatedAt   time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	Status      int       `gorm:"type:int;default:1" json:"status"`  // 1: pending, 2: completed, 3: cancelled
+	Version     int64     `gorm:"not null;default:1" json:"version"` // Incremented by every update (optimistic locking)
+	CreatedAt   time.Time `gorm:"autoCreateTime;index:idx_orders_created_at_id,priority:1" json:"createdAt"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+	// Soft delete: Delete sets deleted_at and queries skip deleted rows unless Unscoped
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt"`
 }
 
 // OrderColumn contains all database column names for Order entity
@@ -23,8 +28,10 @@ var OrderColumn = struct {
 	Quantity    string
 	Amount      string
 	Status      string
+	Version     string
 	CreatedAt   string
 	UpdatedAt   string
+	DeletedAt   string
 }{
 	ID:          "id",
 	UserID:      "user_id",
@@ -32,8 +39,10 @@ var OrderColumn = struct {
 	Quantity:    "quantity",
 	Amount:      "amount",
 	Status:      "status",
+	Version:     "version",
 	CreatedAt:   "created_at",
 	UpdatedAt:   "updated_at",
+	DeletedAt:   "deleted_at",
 }
 
 // OrderTableName is the table name for Order entity
@@ -50,3 +59,8 @@ func (Order) TableName() string {
 	return OrderTableName
 }
 
+// AuditEntity implements Auditable
+func (Order) AuditEntity() string {
+	return "order"
+}
+
@@ -0,0 +1,66 @@
+package entity
+
+import "time"
+
+// OutboxEvent is a domain event written in the transaction of the change it describes and
+// delivered to the configured publisher by the outbox relay (see package outbox)
+type OutboxEvent struct {
+	ID            string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	EventType     string     `gorm:"type:varchar(100);not null" json:"eventType"`             // e.g. order.created
+	AggregateType string     `gorm:"type:varchar(50);not null" json:"aggregateType"`          // e.g. order
+	AggregateID   string     `gorm:"type:varchar(36);not null" json:"aggregateId"`            // ID of the user or order
+	DedupeKey     string     `gorm:"type:varchar(255);not null;uniqueIndex" json:"dedupeKey"` // One event per key; sent to consumers to drop redeliveries
+	Payload       string     `gorm:"type:text;not null" json:"payload"`                       // JSON
+	Status        string     `gorm:"type:varchar(20);not null;default:pending;index:idx_outbox_events_pending,priority:1" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_events_pending,priority:2" json:"nextAttemptAt"` // Also the lease expiry of a claimed event
+	ClaimToken    string     `gorm:"type:varchar(36)" json:"-"`                                                // Set by the relay instance delivering the event
+	LastError     string     `gorm:"type:text" json:"lastError"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	DeliveredAt   *time.Time `json:"deliveredAt"`
+}
+
+// OutboxEventColumn contains all database column names for OutboxEvent entity
+var OutboxEventColumn = struct {
+	ID            string
+	EventType     string
+	AggregateType string
+	AggregateID   string
+	DedupeKey     string
+	Payload       string
+	Status        string
+	Attempts      string
+	NextAttemptAt string
+	ClaimToken    string
+	LastError     string
+	CreatedAt     string
+	DeliveredAt   string
+}{
+	ID:            "id",
+	EventType:     "event_type",
+	AggregateType: "aggregate_type",
+	AggregateID:   "aggregate_id",
+	DedupeKey:     "dedupe_key",
+	Payload:       "payload",
+	Status:        "status",
+	Attempts:      "attempts",
+	NextAttemptAt: "next_attempt_at",
+	ClaimToken:    "claim_token",
+	LastError:     "last_error",
+	CreatedAt:     "created_at",
+	DeliveredAt:   "delivered_at",
+}
+
+// OutboxEventTableName is the table name for OutboxEvent entity
+const OutboxEventTableName = "outbox_events"
+
+// Outbox event statuses
+const (
+	OutboxStatusPending   = "pending"   // Waiting for delivery or a retry
+	OutboxStatusDelivered = "delivered" // Accepted by the publisher
+	OutboxStatusFailed    = "failed"    // Gave up after the maximum attempts
+)
+
+func (OutboxEvent) TableName() string {
+	return OutboxEventTableName
+}
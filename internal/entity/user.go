@@ -2,15 +2,20 @@ package entity
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type User struct {
-	ID        string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	ID        string    `gorm:"primaryKey;type:varchar(36);index:idx_users_created_at_id,priority:2" json:"id"`
 	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
 	Email     string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
 	Status    int       `gorm:"type:int;default:1" json:"status"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	Version   int64     `gorm:"not null;default:1" json:"version"` // Incremented by every update (optimistic locking)
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_users_created_at_id,priority:1" json:"createdAt"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+	// Soft delete: Delete sets deleted_at and queries skip deleted rows unless Unscoped
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt"`
 }
 
 // Column contains all database column names for User entity
@@ -19,15 +24,19 @@ var Column = struct {
 	Name      string
 	Email     string
 	Status    string
+	Version   string
 	CreatedAt string
 	UpdatedAt string
+	DeletedAt string
 }{
 	ID:        "id",
 	Name:      "name",
 	Email:     "email",
 	Status:    "status",
+	Version:   "version",
 	CreatedAt: "created_at",
 	UpdatedAt: "updated_at",
+	DeletedAt: "deleted_at",
 }
 
 // UserTableName is the table name for User entity
@@ -42,3 +51,8 @@ const (
 func (User) TableName() string {
 	return UserTableName
 }
+
+// AuditEntity implements Auditable
+func (User) AuditEntity() string {
+	return "user"
+}
@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"time"
 )
 
 // OrderService defines the interface for order operations across modules.
@@ -16,6 +17,14 @@ type OrderService interface {
 	// GetByID retrieves an order by ID.
 	// Returns order data if found, error if not found or retrieval fails.
 	GetByID(ctx context.Context, id string) (interface{}, error)
+
+	// DeleteByUserID soft-deletes the orders of a user, called when the user is deleted.
+	// Joins the transaction carried by ctx.
+	DeleteByUserID(ctx context.Context, userID string) error
+
+	// RestoreByUserID restores the orders of a user that were deleted at or after deletedSince,
+	// i.e. together with the user. Joins the transaction carried by ctx.
+	RestoreByUserID(ctx context.Context, userID string, deletedSince time.Time) error
 }
 
 // OrderInfo contains minimal order information needed for inter-module communication.
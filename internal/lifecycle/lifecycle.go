@@ -0,0 +1,179 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"llm-aggregator/internal/logger"
+)
+
+// Readiness reports whether the service should receive traffic.
+// It backs the /health/ready probe.
+type Readiness struct {
+	ready atomic.Bool
+}
+
+// NewReadiness creates a readiness flag, initially not ready
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// SetReady updates the readiness flag
+func (r *Readiness) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+// IsReady returns the readiness flag. A nil Readiness is always ready.
+func (r *Readiness) IsReady() bool {
+	return r == nil || r.ready.Load()
+}
+
+// StepFunc releases a resource during shutdown
+type StepFunc func(ctx context.Context) error
+
+type step struct {
+	name    string
+	timeout time.Duration
+	fn      StepFunc
+}
+
+// Manager coordinates background jobs and the ordered shutdown of the application.
+//
+// On Shutdown it:
+//  1. marks the service as not ready, so load balancers stop sending traffic
+//  2. waits for the drain period, so they notice before the listener closes
+//  3. runs the registered shutdown steps in registration order, each with its own timeout
+//
+// Usage:
+//
+//	lc := lifecycle.NewManager(5 * time.Second)
+//	lc.Go("log-cleanup", func(ctx context.Context) { logger.RunCleanupJob(ctx, dir, days) })
+//	lc.OnShutdown("http server", 30*time.Second, srv.Shutdown)
+//	lc.OnShutdown("background jobs", 10*time.Second, lc.StopJobs)
+//	lc.OnShutdown("database", 5*time.Second, closeDB)
+//	...
+//	lc.Shutdown()
+type Manager struct {
+	readiness   *Readiness
+	drainPeriod time.Duration
+
+	jobsCtx  context.Context
+	stopJobs context.CancelFunc
+	jobs     sync.WaitGroup
+
+	mu    sync.Mutex
+	steps []step
+}
+
+// NewManager creates a lifecycle manager that waits drainPeriod between
+// marking the service not ready and running the shutdown steps
+func NewManager(drainPeriod time.Duration) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		readiness:   NewReadiness(),
+		drainPeriod: drainPeriod,
+		jobsCtx:     ctx,
+		stopJobs:    cancel,
+	}
+}
+
+// Readiness returns the readiness flag managed by this manager
+func (m *Manager) Readiness() *Readiness {
+	return m.readiness
+}
+
+// Go runs a background job until StopJobs is called.
+// Jobs such as logger.RunCleanupJob block, so start them in a goroutine with Go rather than
+// calling them directly. fn must return promptly once ctx is cancelled.
+func (m *Manager) Go(name string, fn func(ctx context.Context)) {
+	m.jobs.Add(1)
+	go func() {
+		defer m.jobs.Done()
+		fn(m.jobsCtx)
+		logger.GetLogger().Debug("Background job stopped", zap.String("job", name))
+	}()
+}
+
+// StopJobs cancels every job started with Go and waits until they return or ctx expires
+func (m *Manager) StopJobs(ctx context.Context) error {
+	m.stopJobs()
+
+	done := make(chan struct{})
+	go func() {
+		m.jobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background jobs did not stop: %w", ctx.Err())
+	}
+}
+
+// OnShutdown registers a shutdown step. Steps run in registration order.
+func (m *Manager) OnShutdown(name string, timeout time.Duration, fn StepFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.steps = append(m.steps, step{name: name, timeout: timeout, fn: fn})
+}
+
+// Shutdown drains traffic and runs every shutdown step.
+// A failing or timed out step is logged and does not prevent the following steps.
+// It returns the number of steps that failed.
+func (m *Manager) Shutdown() int {
+	log := logger.GetLogger()
+
+	m.readiness.SetReady(false)
+	if m.drainPeriod > 0 {
+		log.Info("Marked not ready, draining traffic", zap.Duration("drain_period", m.drainPeriod))
+		time.Sleep(m.drainPeriod)
+	}
+
+	m.mu.Lock()
+	steps := m.steps
+	m.mu.Unlock()
+
+	failed := 0
+	for _, s := range steps {
+		start := time.Now()
+		if err := runStep(s); err != nil {
+			failed++
+			log.Error("Shutdown step failed",
+				zap.String("step", s.name),
+				zap.Duration("duration", time.Since(start)),
+				zap.Error(err),
+			)
+			continue
+		}
+		log.Info("Shutdown step completed",
+			zap.String("step", s.name),
+			zap.Duration("duration", time.Since(start)),
+		)
+	}
+	return failed
+}
+
+// runStep runs s with its timeout. Steps that ignore ctx are abandoned when the timeout expires.
+func runStep(s step) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s", s.timeout)
+	}
+}
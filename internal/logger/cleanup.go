@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,20 +11,24 @@ import (
 	"go.uber.org/zap"
 )
 
-// StartCleanupJob starts a background job to clean up old log files
-func StartCleanupJob(directory string, retentionDays int) {
-	go func() {
-		ticker := time.NewTicker(24 * time.Hour) // Run once per day
-		defer ticker.Stop()
-
-		// Run immediately on start
-		cleanupOldLogs(directory, retentionDays)
-
-		// Then run daily
-		for range ticker.C {
+// RunCleanupJob deletes the log files (plain or .gz) dated more than retentionDays ago, on start
+// and then daily, until ctx is cancelled
+func RunCleanupJob(ctx context.Context, directory string, retentionDays int) {
+	ticker := time.NewTicker(24 * time.Hour) // Run once per day
+	defer ticker.Stop()
+
+	// Run immediately on start
+	cleanupOldLogs(directory, retentionDays)
+
+	// Then run daily
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 			cleanupOldLogs(directory, retentionDays)
 		}
-	}()
+	}
 }
 
 // cleanupOldLogs removes log files older than retentionDays
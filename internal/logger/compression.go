@@ -2,6 +2,7 @@ package logger
 
 import (
 	"compress/gzip"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,20 +12,24 @@ import (
 	"go.uber.org/zap"
 )
 
-// StartCompressionJob starts a background job to compress old log files
-func StartCompressionJob(directory string, compressAfterDays int) {
-	go func() {
-		ticker := time.NewTicker(24 * time.Hour) // Run once per day
-		defer ticker.Stop()
+// RunCompressionJob gzips the log files dated more than compressAfterDays ago, on start and then
+// daily, until ctx is cancelled
+func RunCompressionJob(ctx context.Context, directory string, compressAfterDays int) {
+	ticker := time.NewTicker(24 * time.Hour) // Run once per day
+	defer ticker.Stop()
 
-		// Run immediately on start
-		compressOldLogs(directory, compressAfterDays)
+	// Run immediately on start
+	compressOldLogs(directory, compressAfterDays)
 
-		// Then run daily
-		for range ticker.C {
+	// Then run daily
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 			compressOldLogs(directory, compressAfterDays)
 		}
-	}()
+	}
 }
 
 // compressOldLogs compresses log files older than compressAfterDays
@@ -12,13 +12,17 @@ var Logger *zap.Logger
 var fileWriter *DailyFileWriter
 var errorFileWriter *DailyFileWriter
 
+// level is shared by the file and console cores so it can be changed at runtime
+var level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
 // Init initializes the logger with file rotation
 func Init(env, logDirectory, logLevel string) error {
 	// Parse log level
-	var level zapcore.Level
-	if err := level.UnmarshalText([]byte(logLevel)); err != nil {
-		level = zapcore.InfoLevel
+	var parsedLevel zapcore.Level
+	if err := parsedLevel.UnmarshalText([]byte(logLevel)); err != nil {
+		parsedLevel = zapcore.InfoLevel
 	}
+	level.SetLevel(parsedLevel)
 
 	// Create encoder config
 	encoderConfig := zap.NewProductionEncoderConfig()
@@ -84,6 +88,22 @@ func Init(env, logDirectory, logLevel string) error {
 	return nil
 }
 
+// SetLevel changes the minimum level of the main log file and console output at runtime.
+// The error log file always records errors and above.
+func SetLevel(logLevel string) error {
+	var parsedLevel zapcore.Level
+	if err := parsedLevel.UnmarshalText([]byte(logLevel)); err != nil {
+		return fmt.Errorf("invalid log level %q: %w", logLevel, err)
+	}
+	level.SetLevel(parsedLevel)
+	return nil
+}
+
+// GetLevel returns the current minimum log level
+func GetLevel() string {
+	return level.Level().String()
+}
+
 // GetLogger returns the logger instance
 func GetLogger() *zap.Logger {
 	if Logger == nil {
@@ -46,7 +46,35 @@ var (
 	DatabaseConnections = promauto.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "database_connections_active",
-			Help: "Number of active database connections",
+			Help: "Number of open database connections, in use and idle",
+		},
+	)
+
+	DatabaseConnectionsInUse = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "database_connections_in_use",
+			Help: "Number of database connections currently in use",
+		},
+	)
+
+	DatabaseConnectionsIdle = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "database_connections_idle",
+			Help: "Number of idle database connections",
+		},
+	)
+
+	DatabaseConnectionWaitsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "database_connection_waits_total",
+			Help: "Total number of times a query waited for a free database connection",
+		},
+	)
+
+	DatabaseConnectionWaitSeconds = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "database_connection_wait_seconds_total",
+			Help: "Total time spent waiting for a free database connection in seconds",
 		},
 	)
 
@@ -75,6 +103,22 @@ var (
 		[]string{"operation", "table", "error_type"},
 	)
 
+	DatabaseTransactionRetriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "database_transaction_retries_total",
+			Help: "Total number of transactions retried after a deadlock or lock wait timeout",
+		},
+		[]string{"operation", "error_type"},
+	)
+
+	DatabaseTransactionFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "database_transaction_failures_total",
+			Help: "Total number of transactions given up with a retryable error (reason: exhausted, not_idempotent, canceled)",
+		},
+		[]string{"operation", "error_type", "reason"},
+	)
+
 	// Business Logic Metrics
 	BusinessOperationsTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -100,5 +144,29 @@ var (
 		},
 		[]string{"operation", "module", "error_code"},
 	)
+
+	// Outbox Metrics
+	OutboxEventsPublishedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_events_published_total",
+			Help: "Total number of outbox events delivered to the publisher",
+		},
+		[]string{"event_type"},
+	)
+
+	OutboxPublishFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_publish_failures_total",
+			Help: "Total number of failed outbox deliveries (outcome: retry, failed)",
+		},
+		[]string{"event_type", "outcome"},
+	)
+
+	OutboxPendingEvents = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "outbox_pending_events",
+			Help: "Number of outbox events waiting for delivery",
+		},
+	)
 )
 
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"llm-aggregator/internal/database"
+)
+
+// AuditActor sets the actor recorded in audit_logs for the changes made by the request:
+// the verified mTLS client ("cert:<CN>"), else the Basic Auth user ("user:<name>"), else the
+// client IP ("ip:<address>"). It must run after ClientCertificate and the auth middleware.
+func AuditActor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := "ip:" + c.ClientIP()
+		if identity := GetClientIdentity(c); identity != nil && identity.CommonName != "" {
+			actor = "cert:" + identity.CommonName
+		} else if user := c.GetString(gin.AuthUserKey); user != "" {
+			actor = "user:" + user
+		}
+		c.Request = c.Request.WithContext(database.WithActor(c.Request.Context(), actor))
+
+		c.Next()
+	}
+}
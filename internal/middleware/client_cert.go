@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const ClientIdentityKey = "client_identity"
+
+// ClientIdentity describes the verified certificate presented by a mutual TLS client
+type ClientIdentity struct {
+	CommonName   string
+	Organization []string
+	DNSNames     []string
+	URIs         []string // e.g. SPIFFE IDs
+	SerialNumber string
+	Issuer       string
+}
+
+// ClientCertificate exposes the verified client certificate to handlers.
+// Only certificates verified against the configured client CA are used;
+// unverified certificates (client_auth "request" or "require") are ignored.
+func ClientCertificate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := c.Request.TLS
+		if state != nil && len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+			cert := state.VerifiedChains[0][0]
+			identity := &ClientIdentity{
+				CommonName:   cert.Subject.CommonName,
+				Organization: cert.Subject.Organization,
+				DNSNames:     cert.DNSNames,
+				SerialNumber: cert.SerialNumber.String(),
+				Issuer:       cert.Issuer.String(),
+			}
+			for _, uri := range cert.URIs {
+				identity.URIs = append(identity.URIs, uri.String())
+			}
+			c.Set(ClientIdentityKey, identity)
+		}
+
+		c.Next()
+	}
+}
+
+// GetClientIdentity retrieves the verified client identity from context.
+// It returns nil when the request did not present a verified client certificate.
+func GetClientIdentity(c *gin.Context) *ClientIdentity {
+	if value, exists := c.Get(ClientIdentityKey); exists {
+		if identity, ok := value.(*ClientIdentity); ok {
+			return identity
+		}
+	}
+	return nil
+}
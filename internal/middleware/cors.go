@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"strings"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,8 +22,9 @@ func CORS() gin.HandlerFunc {
 		}
 		
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID, If-Match")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag")
 		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
 
 		// Handle preflight requests
@@ -35,12 +37,42 @@ func CORS() gin.HandlerFunc {
 	}
 }
 
+// OriginList holds the allowed CORS origins and can be replaced at runtime
+type OriginList struct {
+	origins atomic.Pointer[[]string]
+}
+
+// NewOriginList creates a new origin list
+func NewOriginList(origins []string) *OriginList {
+	l := &OriginList{}
+	l.Set(origins)
+	return l
+}
+
+// Set replaces the allowed origins
+func (l *OriginList) Set(origins []string) {
+	copied := append([]string(nil), origins...)
+	l.origins.Store(&copied)
+}
+
+// Get returns the current allowed origins
+func (l *OriginList) Get() []string {
+	return *l.origins.Load()
+}
+
 // CORSWithProductionConfig returns a CORS middleware with production-safe configuration
 // allowedOrigins: list of allowed origins. Empty or ["*"] allows all (development mode)
 // isProduction: if true, only allows origins from allowedOrigins list
 func CORSWithProductionConfig(allowedOrigins []string, isProduction bool) gin.HandlerFunc {
+	return CORSWithOriginList(NewOriginList(allowedOrigins), isProduction)
+}
+
+// CORSWithOriginList works like CORSWithProductionConfig but reads the whitelist
+// from originList on each request, so origins can be changed without a restart
+func CORSWithOriginList(originList *OriginList, isProduction bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
+		allowedOrigins := originList.Get()
 		
 		allowed := false
 		
@@ -73,8 +105,9 @@ func CORSWithProductionConfig(allowedOrigins []string, isProduction bool) gin.Ha
 		// If not allowed, don't set header (browser will block)
 		
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID, If-Match")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag")
 		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
 
 		if c.Request.Method == "OPTIONS" {
@@ -109,8 +142,9 @@ func CORSWithConfig(allowedOrigins []string) gin.HandlerFunc {
 		}
 		
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID, If-Match")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag")
 		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
 
 		if c.Request.Method == "OPTIONS" {
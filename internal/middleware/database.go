@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"llm-aggregator/internal/database"
+)
+
+// DatabaseSession tracks the writes of each request, so reads made after a write in the same
+// request go to the primary instead of a read replica that may not have the write yet
+func DatabaseSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(database.WithWriteTracking(c.Request.Context()))
+		c.Next()
+	}
+}
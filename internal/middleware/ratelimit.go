@@ -53,6 +53,27 @@ func (rl *RateLimiter) GetLimiter(key string) *rate.Limiter {
 	return limiter
 }
 
+// SetLimit changes the rate and burst at runtime.
+// Existing per-IP limiters are updated in place so the new limit applies immediately.
+func (rl *RateLimiter) SetLimit(rps float64, burst int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.rate = rate.Limit(rps)
+	rl.burst = burst
+	for _, limiter := range rl.limiters {
+		limiter.SetLimit(rl.rate)
+		limiter.SetBurst(rl.burst)
+	}
+}
+
+// Limit returns the current rate (requests per second) and burst size
+func (rl *RateLimiter) Limit() (float64, int) {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	return float64(rl.rate), rl.burst
+}
+
 // cleanup removes old limiters periodically to prevent memory leak
 func (rl *RateLimiter) cleanup() {
 	ticker := time.NewTicker(1 * time.Hour)
@@ -3,6 +3,8 @@ package middleware
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+
+	"llm-aggregator/internal/logger"
 )
 
 const RequestIDKey = "request_id"
@@ -16,8 +18,9 @@ func RequestID() gin.HandlerFunc {
 			requestID = uuid.New().String()
 		}
 
-		// Set in context
+		// Set in context, and in the request context for code below the handlers (e.g. SQL logs)
 		c.Set(RequestIDKey, requestID)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
 
 		// Set in response header
 		c.Header("X-Request-ID", requestID)
@@ -2,17 +2,45 @@ package middleware
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// TimeoutValue holds a request timeout that can be changed at runtime
+type TimeoutValue struct {
+	d atomic.Int64
+}
+
+// NewTimeoutValue creates a new timeout holder
+func NewTimeoutValue(timeout time.Duration) *TimeoutValue {
+	v := &TimeoutValue{}
+	v.Set(timeout)
+	return v
+}
+
+// Set changes the timeout used by subsequent requests
+func (v *TimeoutValue) Set(timeout time.Duration) {
+	v.d.Store(int64(timeout))
+}
+
+// Get returns the current timeout
+func (v *TimeoutValue) Get() time.Duration {
+	return time.Duration(v.d.Load())
+}
+
 // Timeout returns a middleware that sets a timeout for the request context
 // Default timeout: 30 seconds
 func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return TimeoutWithValue(NewTimeoutValue(timeout))
+}
+
+// TimeoutWithValue returns a timeout middleware reading the timeout from value on each request
+func TimeoutWithValue(value *TimeoutValue) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Create context with timeout
-		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), value.Get())
 		defer cancel()
 
 		// Replace request context
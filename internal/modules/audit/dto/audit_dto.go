@@ -0,0 +1,32 @@
+package dto
+
+import "encoding/json"
+
+type AuditPagingRequest struct {
+	Page     int    `form:"page" binding:"omitempty,min=1" validate:"omitempty,min=1"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=100" validate:"omitempty,min=1,max=100"`
+	Entity   string `form:"entity" binding:"required_with=EntityID,omitempty,oneof=user order" validate:"required_with=EntityID,omitempty,oneof=user order"`
+	EntityID string `form:"id" binding:"omitempty" validate:"omitempty"`
+	Action   string `form:"action" binding:"omitempty,oneof=create update delete restore purge" validate:"omitempty,oneof=create update delete restore purge"`
+}
+
+type AuditLogResponse struct {
+	ID        string          `json:"id"`
+	Entity    string          `json:"entity"`
+	EntityID  string          `json:"entityId"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	RequestID string          `json:"requestId,omitempty"`
+	OldValues json.RawMessage `json:"oldValues,omitempty" swaggertype:"object"` // Changed columns before the change (all columns on purge)
+	NewValues json.RawMessage `json:"newValues,omitempty" swaggertype:"object"` // Changed columns after the change (all columns on create)
+	CreatedAt string          `json:"createdAt"`
+}
+
+// AuditPagingResponse is a pagination response specific to Audit module
+type AuditPagingResponse struct {
+	Data       []AuditLogResponse `json:"data"`
+	Page       int                `json:"page"`
+	Limit      int                `json:"limit"`
+	Total      int64              `json:"total"`
+	TotalPages int                `json:"totalPages"`
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"llm-aggregator/internal/common"
+	"llm-aggregator/internal/modules/audit/dto"
+	"llm-aggregator/internal/modules/audit/service"
+)
+
+type AuditHandler struct {
+	service service.AuditService
+}
+
+func NewAuditHandler(service service.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// GetAll handles GET /audit
+// @Summary     Get audit logs
+// @Description Get the recorded creates, updates and deletes of users and orders, newest first
+// @Tags        audit
+// @Accept      json
+// @Produce     json
+// @Param       entity query    string false "Entity type (user, order); required with id"
+// @Param       id     query    string false "Entity ID"
+// @Param       action query    string false "Action (create, update, delete, restore, purge)"
+// @Param       page   query    int    false "Page number" default(1)
+// @Param       limit  query    int    false "Items per page" default(10)
+// @Success     200    {object} common.SuccessResponseWithPaginationDoc{data=[]dto.AuditLogResponse}
+// @Failure     400    {object} common.ErrorResponseDoc "Bad Request - Error code: BAD_REQUEST"
+// @Failure     500    {object} common.ErrorResponseDoc "Internal Server Error - Error code: INTERNAL_ERROR"
+// @Router      /audit [get]
+func (h *AuditHandler) GetAll(c *gin.Context) {
+	var req dto.AuditPagingRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.RespondBadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.service.GetAll(c.Request.Context(), &req)
+	if err != nil {
+		common.RespondServiceError(c, err)
+		return
+	}
+
+	common.RespondSuccessWithPagination(c, result.Data, result.Page, result.Limit, result.Total)
+}
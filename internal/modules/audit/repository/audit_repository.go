@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"llm-aggregator/internal/common"
+	"llm-aggregator/internal/entity"
+	"llm-aggregator/internal/store"
+)
+
+// AuditRepository reads audit_logs; the entries are written by the audit GORM plugin
+// (see internal/database/audit.go), not by this repository
+type AuditRepository interface {
+	FindAllWithFilters(ctx context.Context, entityType, entityID, action string, page, limit int) ([]entity.AuditLog, int64, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) FindAllWithFilters(ctx context.Context, entityType, entityID, action string, page, limit int) ([]entity.AuditLog, int64, error) {
+	query := store.NewQuery[entity.AuditLog](common.DBFromContext(ctx, r.db))
+
+	if entityType != "" {
+		query = query.Eq(entity.AuditLogColumn.EntityType, entityType)
+	}
+	if entityID != "" {
+		query = query.Eq(entity.AuditLogColumn.EntityID, entityID)
+	}
+	if action != "" {
+		query = query.Eq(entity.AuditLogColumn.Action, action)
+	}
+
+	// Newest first; id breaks ties between entries of the same statement
+	query = query.OrderBy(entity.AuditLogColumn.CreatedAt, entity.OrderDESC).
+		OrderBy(entity.AuditLogColumn.ID, entity.OrderDESC)
+	query = query.Page(page, limit)
+
+	total, err := query.Count()
+	if err != nil {
+		return nil, 0, common.WrapError(err, "failed to count audit logs")
+	}
+
+	var logs []entity.AuditLog
+	if err := query.Find(&logs); err != nil {
+		return nil, 0, common.WrapError(err, "failed to find audit logs")
+	}
+
+	return logs, total, nil
+}
@@ -0,0 +1,23 @@
+package audit
+
+import (
+	"gorm.io/gorm"
+
+	"llm-aggregator/internal/modules/audit/handler"
+	"llm-aggregator/internal/modules/audit/repository"
+	"llm-aggregator/internal/modules/audit/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes registers all routes for the audit module
+// r should be a router group (e.g., /api/v1) not the root router
+func RegisterRoutes(r gin.IRouter, db *gorm.DB) {
+	// Initialize dependencies
+	auditRepo := repository.NewAuditRepository(db)
+	auditService := service.NewAuditService(auditRepo)
+	auditHandler := handler.NewAuditHandler(auditService)
+
+	// Define routes - r is already /api/v1 group, so just add /audit
+	r.GET("/audit", auditHandler.GetAll)
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"llm-aggregator/internal/common"
+	"llm-aggregator/internal/entity"
+	"llm-aggregator/internal/modules/audit/dto"
+	"llm-aggregator/internal/modules/audit/repository"
+)
+
+type AuditService interface {
+	GetAll(ctx context.Context, req *dto.AuditPagingRequest) (*dto.AuditPagingResponse, error)
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+func (s *auditService) GetAll(ctx context.Context, req *dto.AuditPagingRequest) (*dto.AuditPagingResponse, error) {
+	// Set defaults using common helper
+	req.Page, req.Limit = common.ValidatePagination(req.Page, req.Limit, common.DefaultPaginationLimit)
+
+	logs, total, err := s.repo.FindAllWithFilters(ctx, req.Entity, req.EntityID, req.Action, req.Page, req.Limit)
+	if err != nil {
+		return nil, common.HandleRepositoryError(err, "", "", "Failed to get audit logs")
+	}
+
+	responses := make([]dto.AuditLogResponse, len(logs))
+	for i := range logs {
+		responses[i] = toAuditLogResponse(&logs[i])
+	}
+
+	return &dto.AuditPagingResponse{
+		Data:       responses,
+		Page:       req.Page,
+		Limit:      req.Limit,
+		Total:      total,
+		TotalPages: common.CalculateTotalPages(total, req.Limit),
+	}, nil
+}
+
+func toAuditLogResponse(log *entity.AuditLog) dto.AuditLogResponse {
+	response := dto.AuditLogResponse{
+		ID:        log.ID,
+		Entity:    log.EntityType,
+		EntityID:  log.EntityID,
+		Action:    log.Action,
+		Actor:     log.Actor,
+		RequestID: log.RequestID,
+		CreatedAt: log.CreatedAt.Format(time.RFC3339Nano),
+	}
+	if log.OldValues != "" {
+		response.OldValues = json.RawMessage(log.OldValues)
+	}
+	if log.NewValues != "" {
+		response.NewValues = json.RawMessage(log.NewValues)
+	}
+	return response
+}
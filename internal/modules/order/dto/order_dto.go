@@ -1,5 +1,12 @@
 package dto
 
+import (
+	"time"
+
+	"llm-aggregator/internal/entity"
+	"llm-aggregator/internal/store"
+)
+
 type CreateOrderRequest struct {
 	UserID      string  `json:"userId" binding:"required" validate:"required"`
 	ProductName string  `json:"productName" binding:"required,min=1,max=255" validate:"required,min=1,max=255"`
@@ -24,23 +31,57 @@ type OrderResponse struct {
 	Amount      float64 `json:"amount"`
 	Status      int     `json:"status"`
 	StatusText  string  `json:"statusText"`
+	Version     int64   `json:"version"` // Also sent as the ETag header; send it back in If-Match to update
+	DeletedAt   *string `json:"deletedAt,omitempty"` // Set on soft-deleted orders (listed with includeDeleted=true)
 	CreatedAt   string  `json:"createdAt"`
 	UpdatedAt   string  `json:"updatedAt"`
 }
 
+// OrderCreatedEvent is the payload of the order.created outbox event
+type OrderCreatedEvent struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"userId"`
+	ProductName string    `json:"productName"`
+	Quantity    int       `json:"quantity"`
+	Amount      float64   `json:"amount"`
+	Status      int       `json:"status"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
 type OrderPagingRequest struct {
 	Page       int    `form:"page" binding:"omitempty,min=1" validate:"omitempty,min=1"`
 	Limit      int    `form:"limit" binding:"omitempty,min=1,max=100" validate:"omitempty,min=1,max=100"`
 	UserID     string `form:"userId" binding:"omitempty" validate:"omitempty"`
 	ProductName string `form:"productName" binding:"omitempty" validate:"omitempty"`
 	Status     *int   `form:"status" binding:"omitempty,oneof=1 2 3" validate:"omitempty,oneof=1 2 3"`
+	IncludeDeleted bool `form:"includeDeleted"` // Also list soft-deleted orders (admin)
+	// Cursor switches to cursor pagination: empty for the first page, then nextCursor or
+	// prevCursor of the previous response. Cannot be combined with page.
+	Cursor *string `form:"cursor"`
+	// List holds the filter[...] and sort parameters, parsed with OrderListFields by the handler
+	List *store.ListQuery `form:"-"`
+}
+
+// OrderListFields are the fields GET /orders can be filtered (filter[...]) and sorted (sort) by
+var OrderListFields = store.Fields{
+	"id":          {Column: entity.OrderColumn.ID, Type: store.FieldString},
+	"userId":      {Column: entity.OrderColumn.UserID, Type: store.FieldString},
+	"productName": {Column: entity.OrderColumn.ProductName, Type: store.FieldString},
+	"quantity":    {Column: entity.OrderColumn.Quantity, Type: store.FieldInt},
+	"amount":      {Column: entity.OrderColumn.Amount, Type: store.FieldFloat},
+	"status":      {Column: entity.OrderColumn.Status, Type: store.FieldInt},
+	"version":     {Column: entity.OrderColumn.Version, Type: store.FieldInt},
+	"createdAt":   {Column: entity.OrderColumn.CreatedAt, Type: store.FieldTime},
+	"updatedAt":   {Column: entity.OrderColumn.UpdatedAt, Type: store.FieldTime},
 }
 
 type OrderPagingResponse struct {
 	Data       []OrderResponse `json:"data"`
 	Page       int             `json:"page"`
 	Limit      int             `json:"limit"`
-	Total      int64           `json:"total"`
+	Total      int64           `json:"total"` // Cursor pagination: first page only, 0 on the others
 	TotalPages int             `json:"totalPages"`
+	NextCursor string          `json:"nextCursor,omitempty"` // Cursor pagination only
+	PrevCursor string          `json:"prevCursor,omitempty"` // Cursor pagination only
 }
 
@@ -5,6 +5,7 @@ import (
 	"llm-aggregator/internal/modules/order/dto"
 	"llm-aggregator/internal/modules/order/service"
 	"llm-aggregator/internal/modules/order/validator"
+	"llm-aggregator/internal/store"
 
 	"github.com/gin-gonic/gin"
 )
@@ -65,6 +66,10 @@ func (h *OrderHandler) Create(c *gin.Context) {
 // @Param       userId      query    string false "Filter by user ID"
 // @Param       productName query    string false "Filter by product name"
 // @Param       status      query    int    false "Filter by status (1=pending, 2=completed, 3=cancelled)"
+// @Param       includeDeleted query bool   false "Also list soft-deleted orders"
+// @Param       cursor      query    string false "Cursor pagination: empty for the first page, then pagination.nextCursor or prevCursor; not with page"
+// @Param       filter[status][in] query string false "Filter by field and operator: filter[<field>][eq|ne|gt|gte|lt|lte|in|like]=<value>, e.g. filter[amount][gte]=10"
+// @Param       sort        query    string false "Sort fields, descending with -, e.g. -createdAt,productName; not with cursor"
 // @Success     200         {object} common.Response{data=dto.OrderPagingResponse}
 // @Failure     500         {object} common.Response
 // @Router      /orders [get]
@@ -75,8 +80,23 @@ func (h *OrderHandler) GetAll(c *gin.Context) {
 		return
 	}
 
+	if req.Cursor != nil && req.Page > 0 {
+		common.RespondBadRequest(c, "page cannot be combined with cursor")
+		return
+	}
+	list, err := store.ParseListQuery(c.Request.URL.Query(), dto.OrderListFields)
+	if err != nil {
+		common.RespondBadRequest(c, err.Error())
+		return
+	}
+	if req.Cursor != nil && len(list.Sorts) > 0 {
+		common.RespondBadRequest(c, "sort cannot be combined with cursor")
+		return
+	}
+	req.List = list
+
 	// Set defaults
-	if req.Page <= 0 {
+	if req.Cursor == nil && req.Page <= 0 {
 		req.Page = 1
 	}
 	if req.Limit <= 0 {
@@ -89,6 +109,16 @@ func (h *OrderHandler) GetAll(c *gin.Context) {
 		return
 	}
 
+	if req.Cursor != nil {
+		// The total is counted on the first page only
+		var total *int64
+		if *req.Cursor == "" {
+			total = &orders.Total
+		}
+		common.RespondSuccessWithCursor(c, orders.Data, orders.Limit, total, orders.NextCursor, orders.PrevCursor)
+		return
+	}
+
 	common.RespondSuccessWithPagination(c, orders.Data, orders.Page, orders.Limit, orders.Total)
 }
 
@@ -100,6 +130,7 @@ func (h *OrderHandler) GetAll(c *gin.Context) {
 // @Produce     json
 // @Param       id   path     string true "Order ID"
 // @Success     200  {object} common.Response{data=dto.OrderResponse}
+// @Header      200  {string} ETag "Order version, to send in If-Match"
 // @Failure     404  {object} common.Response
 // @Failure     500  {object} common.Response
 // @Router      /orders/{id} [get]
@@ -116,6 +147,7 @@ func (h *OrderHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	common.SetETag(c, order.Version)
 	common.RespondSuccess(c, order)
 }
 
@@ -125,12 +157,15 @@ func (h *OrderHandler) GetByID(c *gin.Context) {
 // @Tags        orders
 // @Accept      json
 // @Produce     json
-// @Param       id     path     string                true "Order ID"
-// @Param       order  body     dto.UpdateOrderRequest true "Order data"
-// @Success     200    {object} common.Response
-// @Failure     400    {object} common.Response
-// @Failure     404    {object} common.Response
-// @Failure     500    {object} common.Response
+// @Param       id       path     string                 true  "Order ID"
+// @Param       If-Match header   string                 false "ETag from GET; fails with VERSION_CONFLICT when the order changed since"
+// @Param       order    body     dto.UpdateOrderRequest true  "Order data"
+// @Success     200      {object} common.Response
+// @Header      200      {string} ETag "New order version"
+// @Failure     400      {object} common.Response
+// @Failure     404      {object} common.Response
+// @Failure     409      {object} common.Response "VERSION_CONFLICT"
+// @Failure     500      {object} common.Response
 // @Router      /orders/{id} [put]
 func (h *OrderHandler) Update(c *gin.Context) {
 	id := c.Param("id")
@@ -150,11 +185,19 @@ func (h *OrderHandler) Update(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Update(c.Request.Context(), id, &req); err != nil {
+	version, err := common.IfMatchVersion(c)
+	if err != nil {
+		common.RespondBadRequest(c, err.Error())
+		return
+	}
+
+	newVersion, err := h.service.Update(c.Request.Context(), id, &req, version)
+	if err != nil {
 		common.RespondServiceError(c, err)
 		return
 	}
 
+	common.SetETag(c, newVersion)
 	common.RespondSuccess(c, nil)
 }
 
@@ -164,10 +207,12 @@ func (h *OrderHandler) Update(c *gin.Context) {
 // @Tags        orders
 // @Accept      json
 // @Produce     json
-// @Param       id   path     string true "Order ID"
-// @Success     200  {object} common.Response
-// @Failure     404  {object} common.Response
-// @Failure     500  {object} common.Response
+// @Param       id       path     string true  "Order ID"
+// @Param       If-Match header   string false "ETag from GET; fails with VERSION_CONFLICT when the order changed since"
+// @Success     200      {object} common.Response
+// @Failure     404      {object} common.Response
+// @Failure     409      {object} common.Response "VERSION_CONFLICT"
+// @Failure     500      {object} common.Response
 // @Router      /orders/{id} [delete]
 func (h *OrderHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
@@ -176,7 +221,13 @@ func (h *OrderHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+	version, err := common.IfMatchVersion(c)
+	if err != nil {
+		common.RespondBadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id, version); err != nil {
 		common.RespondServiceError(c, err)
 		return
 	}
@@ -184,6 +235,35 @@ func (h *OrderHandler) Delete(c *gin.Context) {
 	common.RespondSuccess(c, nil)
 }
 
+// Restore handles POST /orders/:id/restore
+// @Summary     Restore an order
+// @Description Undo the soft delete of an order. Orders of a deleted user are restored with the user.
+// @Tags        orders
+// @Accept      json
+// @Produce     json
+// @Param       id   path     string true "Order ID"
+// @Success     200  {object} common.Response{data=dto.OrderResponse}
+// @Header      200  {string} ETag "Order version, to send in If-Match"
+// @Failure     404  {object} common.Response "NOT_FOUND, or USER_NOT_FOUND when the user of the order is deleted"
+// @Failure     500  {object} common.Response
+// @Router      /orders/{id}/restore [post]
+func (h *OrderHandler) Restore(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		common.RespondBadRequest(c, "Order ID is required")
+		return
+	}
+
+	order, err := h.service.Restore(c.Request.Context(), id)
+	if err != nil {
+		common.RespondServiceError(c, err)
+		return
+	}
+
+	common.SetETag(c, order.Version)
+	common.RespondSuccess(c, order)
+}
+
 // GetByUserID handles GET /orders/user/:userId
 // @Summary     Get orders by user ID
 // @Description Get all orders for a specific user
@@ -3,19 +3,31 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 
 	"llm-aggregator/internal/common"
 	"llm-aggregator/internal/entity"
+	"llm-aggregator/internal/store"
 )
 
 type OrderRepository interface {
 	Create(ctx context.Context, order *entity.Order) error
 	Update(ctx context.Context, order *entity.Order) error
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string, version int64) error
 	FindByID(ctx context.Context, id string) (*entity.Order, error)
-	FindAllWithFilters(ctx context.Context, userID, productName string, status *int, page, limit int) ([]entity.Order, int64, error)
+	FindByIDWithDeleted(ctx context.Context, id string) (*entity.Order, error)
+	Restore(ctx context.Context, id string) error
+	DeleteByUserID(ctx context.Context, userID string) (int64, error)
+	RestoreByUserID(ctx context.Context, userID string, deletedSince time.Time) (int64, error)
+	// FindAllWithFilters also applies list (filter[...] and sort, may be nil); without sort,
+	// orders are listed newest first
+	FindAllWithFilters(ctx context.Context, userID, productName string, status *int, includeDeleted bool, list *store.ListQuery, page, limit int) ([]entity.Order, int64, error)
+	// FindPageWithFilters is FindAllWithFilters with cursor pagination; it returns
+	// store.ErrInvalidCursor when cursor cannot be used. list must not sort. The total is
+	// counted on the first page (empty cursor) only, and 0 on the others.
+	FindPageWithFilters(ctx context.Context, userID, productName string, status *int, includeDeleted bool, list *store.ListQuery, cursor string, limit int) ([]entity.Order, *store.CursorPage, int64, error)
 	FindByUserID(ctx context.Context, userID string, page, limit int) ([]entity.Order, int64, error)
 	WithTx(tx *gorm.DB) OrderRepository
 }
@@ -31,20 +43,24 @@ func NewOrderRepository(db *gorm.DB) OrderRepository {
 }
 
 func (r *orderRepository) Create(ctx context.Context, order *entity.Order) error {
-	if err := r.db.WithContext(ctx).Create(order).Error; err != nil {
+	if err := common.DBFromContext(ctx, r.db).Create(order).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
+// Update writes order if it still has the version it was read with, and increments the version.
+// Returns common.ErrVersionConflict when the order was changed in the meantime.
 func (r *orderRepository) Update(ctx context.Context, order *entity.Order) error {
-	result := r.db.WithContext(ctx).Model(&entity.Order{}).
-		Where("id = ?", order.ID).
+	db := common.DBFromContext(ctx, r.db)
+	result := db.Model(&entity.Order{}).
+		Where("id = ? AND version = ?", order.ID, order.Version).
 		Updates(map[string]interface{}{
 			"product_name": order.ProductName,
 			"quantity":     order.Quantity,
 			"amount":       order.Amount,
 			"status":       order.Status,
+			"version":      order.Version + 1,
 		})
 
 	if result.Error != nil {
@@ -52,19 +68,29 @@ func (r *orderRepository) Update(ctx context.Context, order *entity.Order) error
 	}
 
 	if result.RowsAffected == 0 {
-		return common.ErrNotFound
+		return common.VersionMissError(db, &entity.Order{}, order.ID)
 	}
 
+	order.Version++
 	return nil
 }
 
-func (r *orderRepository) Delete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&entity.Order{})
+// Delete soft-deletes the order id; a non-zero version makes the delete conditional like Update
+func (r *orderRepository) Delete(ctx context.Context, id string, version int64) error {
+	db := common.DBFromContext(ctx, r.db)
+	query := db.Where("id = ?", id)
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+	result := query.Delete(&entity.Order{})
 	if result.Error != nil {
 		return result.Error
 	}
 
 	if result.RowsAffected == 0 {
+		if version != 0 {
+			return common.VersionMissError(db, &entity.Order{}, id)
+		}
 		return common.ErrNotFound
 	}
 
@@ -73,7 +99,7 @@ func (r *orderRepository) Delete(ctx context.Context, id string) error {
 
 func (r *orderRepository) FindByID(ctx context.Context, id string) (*entity.Order, error) {
 	var order entity.Order
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&order).Error; err != nil {
+	if err := common.DBFromContext(ctx, r.db).Where("id = ?", id).First(&order).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, common.ErrNotFound
 		}
@@ -82,43 +108,111 @@ func (r *orderRepository) FindByID(ctx context.Context, id string) (*entity.Orde
 	return &order, nil
 }
 
-func (r *orderRepository) FindAllWithFilters(ctx context.Context, userID, productName string, status *int, page, limit int) ([]entity.Order, int64, error) {
-	var orders []entity.Order
-	var total int64
+// FindByIDWithDeleted finds the order id, soft-deleted or not
+func (r *orderRepository) FindByIDWithDeleted(ctx context.Context, id string) (*entity.Order, error) {
+	var order entity.Order
+	if err := common.DBFromContext(ctx, r.db).Unscoped().Where("id = ?", id).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	return &order, nil
+}
 
-	query := r.db.WithContext(ctx).Model(&entity.Order{})
+// Restore undoes the soft delete of the order id and increments its version.
+// Restoring an order that is not deleted does nothing.
+func (r *orderRepository) Restore(ctx context.Context, id string) error {
+	return common.DBFromContext(ctx, r.db).Unscoped().Model(&entity.Order{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+}
 
-	if userID != "" {
-		query = query.Where("user_id = ?", userID)
-	}
+// DeleteByUserID soft-deletes the orders of a user and returns how many were deleted
+func (r *orderRepository) DeleteByUserID(ctx context.Context, userID string) (int64, error) {
+	result := common.DBFromContext(ctx, r.db).Where("user_id = ?", userID).Delete(&entity.Order{})
+	return result.RowsAffected, result.Error
+}
 
-	if productName != "" {
-		query = query.Where("product_name LIKE ?", "%"+productName+"%")
-	}
+// RestoreByUserID restores the orders of a user deleted at or after deletedSince, i.e. those
+// deleted together with the user, and returns how many were restored
+func (r *orderRepository) RestoreByUserID(ctx context.Context, userID string, deletedSince time.Time) (int64, error) {
+	result := common.DBFromContext(ctx, r.db).Unscoped().Model(&entity.Order{}).
+		Where("user_id = ? AND deleted_at >= ?", userID, deletedSince).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		})
+	return result.RowsAffected, result.Error
+}
 
-	if status != nil {
-		query = query.Where("status = ?", *status)
-	}
+func (r *orderRepository) FindAllWithFilters(ctx context.Context, userID, productName string, status *int, includeDeleted bool, list *store.ListQuery, page, limit int) ([]entity.Order, int64, error) {
+	query := r.filtered(ctx, userID, productName, status, includeDeleted, list)
 
 	// Count total
-	if err := query.Count(&total).Error; err != nil {
+	total, err := query.Count()
+	if err != nil {
 		return nil, 0, err
 	}
 
-	// Apply pagination
-	offset := (page - 1) * limit
-	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&orders).Error; err != nil {
+	// Apply pagination; created_at breaks ties of the sort of list, if any
+	var orders []entity.Order
+	err = query.
+		OrderBy(entity.OrderColumn.CreatedAt, entity.OrderDESC).
+		Page(page, limit).
+		Find(&orders)
+	if err != nil {
 		return nil, 0, err
 	}
 
 	return orders, total, nil
 }
 
+func (r *orderRepository) FindPageWithFilters(ctx context.Context, userID, productName string, status *int, includeDeleted bool, list *store.ListQuery, cursor string, limit int) ([]entity.Order, *store.CursorPage, int64, error) {
+	// Count total on the first page only: a COUNT scans every matching row, which the keyset
+	// query of the following pages avoids
+	var total int64
+	if cursor == "" {
+		var err error
+		if total, err = r.filtered(ctx, userID, productName, status, includeDeleted, list).Count(); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+
+	var orders []entity.Order
+	page, err := r.filtered(ctx, userID, productName, status, includeDeleted, list).
+		Keyset(entity.OrderColumn.CreatedAt, entity.OrderDESC, cursor, limit).
+		FindPage(&orders)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	return orders, page, total, nil
+}
+
+// filtered returns a query for the orders matching the list filters
+func (r *orderRepository) filtered(ctx context.Context, userID, productName string, status *int, includeDeleted bool, list *store.ListQuery) *store.Query[entity.Order] {
+	query := store.NewQuery[entity.Order](common.DBFromContext(ctx, r.db)).Unscoped(includeDeleted)
+	if userID != "" {
+		query = query.Eq(entity.OrderColumn.UserID, userID)
+	}
+	if productName != "" {
+		query = query.Like(entity.OrderColumn.ProductName, productName)
+	}
+	if status != nil {
+		query = query.Eq(entity.OrderColumn.Status, *status)
+	}
+	return query.Apply(list)
+}
+
 func (r *orderRepository) FindByUserID(ctx context.Context, userID string, page, limit int) ([]entity.Order, int64, error) {
 	var orders []entity.Order
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&entity.Order{}).Where("user_id = ?", userID)
+	query := common.DBFromContext(ctx, r.db).Model(&entity.Order{}).Where("user_id = ?", userID)
 
 	// Count total
 	if err := query.Count(&total).Error; err != nil {
@@ -36,6 +36,7 @@ func RegisterRoutes(r gin.IRouter, db *gorm.DB, container *container.ModuleConta
 		orders.GET("/:id", orderHandler.GetByID)
 		orders.PUT("/:id", orderHandler.Update)
 		orders.DELETE("/:id", orderHandler.Delete)
+		orders.POST("/:id/restore", orderHandler.Restore)
 		orders.GET("/user/:userId", orderHandler.GetByUserID)
 	}
 
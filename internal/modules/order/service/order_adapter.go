@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"llm-aggregator/internal/interfaces"
 )
@@ -22,6 +23,16 @@ func (a *orderServiceAdapter) GetByID(ctx context.Context, id string) (interface
 	return a.service.GetByID(ctx, id)
 }
 
+// DeleteByUserID implements interfaces.OrderService
+func (a *orderServiceAdapter) DeleteByUserID(ctx context.Context, userID string) error {
+	return a.service.DeleteByUserID(ctx, userID)
+}
+
+// RestoreByUserID implements interfaces.OrderService
+func (a *orderServiceAdapter) RestoreByUserID(ctx context.Context, userID string, deletedSince time.Time) error {
+	return a.service.RestoreByUserID(ctx, userID, deletedSince)
+}
+
 // Ensure orderServiceAdapter implements the interface
 var _ interfaces.OrderService = (*orderServiceAdapter)(nil)
 
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,12 +14,23 @@ import (
 	"llm-aggregator/internal/interfaces"
 	"llm-aggregator/internal/modules/order/dto"
 	"llm-aggregator/internal/modules/order/repository"
+	"llm-aggregator/internal/outbox"
+	"llm-aggregator/internal/store"
 )
 
 type OrderService interface {
 	Create(ctx context.Context, req *dto.CreateOrderRequest) (*dto.OrderResponse, error)
-	Update(ctx context.Context, id string, req *dto.UpdateOrderRequest) error
-	Delete(ctx context.Context, id string) error
+	// Update applies req and returns the new version. A non-zero version (from If-Match) must
+	// match the current one, otherwise the update fails with VERSION_CONFLICT.
+	Update(ctx context.Context, id string, req *dto.UpdateOrderRequest, version int64) (int64, error)
+	// Delete soft-deletes the order; a non-zero version must match like in Update
+	Delete(ctx context.Context, id string, version int64) error
+	// Restore undoes the soft delete of an order whose user is not deleted
+	Restore(ctx context.Context, id string) (*dto.OrderResponse, error)
+	// DeleteByUserID soft-deletes the orders of a user being deleted
+	DeleteByUserID(ctx context.Context, userID string) error
+	// RestoreByUserID restores the orders deleted together with a user, at or after deletedSince
+	RestoreByUserID(ctx context.Context, userID string, deletedSince time.Time) error
 	GetByID(ctx context.Context, id string) (*dto.OrderResponse, error)
 	GetAll(ctx context.Context, req *dto.OrderPagingRequest) (*dto.OrderPagingResponse, error)
 	GetByUserID(ctx context.Context, userID string, page, limit int) (*dto.OrderPagingResponse, error)
@@ -27,7 +39,8 @@ type OrderService interface {
 type orderService struct {
 	repo      repository.OrderRepository
 	container *container.ModuleContainer
-	db        *gorm.DB
+	uow       *common.UnitOfWork
+	outbox    *outbox.Writer // order.created events; nil without db
 }
 
 func NewOrderService(repo repository.OrderRepository, container *container.ModuleContainer) OrderService {
@@ -43,26 +56,12 @@ func NewOrderServiceWithDB(repo repository.OrderRepository, container *container
 	return &orderService{
 		repo:      repo,
 		container: container,
-		db:        db,
+		uow:       common.NewUnitOfWork(db),
+		outbox:    outbox.NewWriter(db),
 	}
 }
 
 func (s *orderService) Create(ctx context.Context, req *dto.CreateOrderRequest) (*dto.OrderResponse, error) {
-	// Verify user exists and check if user is active
-	// This combines verification and status check in one call to avoid duplicate lookups
-	user, err := s.getUserForValidation(ctx, req.UserID)
-	if err != nil {
-		return nil, err
-	}
-	if user != nil && user.Status == 0 {
-		return nil, common.NewServiceError(
-			common.ErrInvalid,
-			"User is inactive",
-			common.ErrorCodeInvalid,
-		)
-	}
-
-	// Create new order
 	order := &entity.Order{
 		ID:          uuid.New().String(),
 		UserID:      req.UserID,
@@ -70,67 +69,170 @@ func (s *orderService) Create(ctx context.Context, req *dto.CreateOrderRequest)
 		Quantity:    req.Quantity,
 		Amount:      req.Amount,
 		Status:      entity.OrderStatusPending,
+		Version:     1,
 	}
 
-	// Create order (with transaction support if db is available)
-	if s.db != nil {
-		// Use transaction for atomic operation
-		err := common.TransactionWithContext(ctx, s.db, func(tx *gorm.DB) error {
-			txRepo := s.repo.WithTx(tx)
-			return txRepo.Create(ctx, order)
-		})
+	// The user check and the insert run in one transaction (when db is available);
+	// the User module joins it through ctx. Retried as a whole on deadlocks.
+	err := s.inTransaction(ctx, "order.create", func(ctx context.Context) error {
+		// Verify user exists and check if user is active
+		// This combines verification and status check in one call to avoid duplicate lookups
+		user, err := s.getUserForValidation(ctx, req.UserID)
 		if err != nil {
-			return nil, common.HandleRepositoryError(err, "", "", "Failed to create order")
+			return err
+		}
+		if user != nil && user.Status == 0 {
+			return common.NewServiceError(
+				common.ErrInvalid,
+				"User is inactive",
+				common.ErrorCodeInvalid,
+			)
 		}
-	} else {
-		// Fallback to non-transactional create
+
 		if err := s.repo.Create(ctx, order); err != nil {
-			return nil, common.HandleRepositoryError(err, "", "", "Failed to create order")
+			return err
 		}
+		// Written in the transaction of the order, so it is published if and only if the order is
+		return s.publish(ctx, outbox.Event{
+			Type:          outbox.EventOrderCreated,
+			AggregateType: "order",
+			AggregateID:   order.ID,
+			DedupeKey:     outbox.EventOrderCreated + ":" + order.ID,
+			Payload: dto.OrderCreatedEvent{
+				ID:          order.ID,
+				UserID:      order.UserID,
+				ProductName: order.ProductName,
+				Quantity:    order.Quantity,
+				Amount:      order.Amount,
+				Status:      order.Status,
+				CreatedAt:   order.CreatedAt,
+			},
+		})
+	})
+	if err != nil {
+		return nil, common.HandleTransactionError(err, "Failed to create order")
 	}
 
 	return s.toOrderResponse(ctx, order)
 }
 
-func (s *orderService) Update(ctx context.Context, id string, req *dto.UpdateOrderRequest) error {
-	// Check if order exists
-	order, err := s.repo.FindByID(ctx, id)
-	if err != nil {
-		return common.HandleRepositoryError(err, "Order not found", common.ErrorCodeNotFound, "Failed to get order")
+// inTransaction runs fn in a unit of work retried on deadlocks and lock wait timeouts,
+// or directly when the service has no database
+func (s *orderService) inTransaction(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	if s.uow == nil {
+		return fn(ctx)
 	}
+	return s.uow.DoWithRetry(ctx, operation, fn)
+}
 
-	// Update fields
-	if req.ProductName != "" {
-		order.ProductName = req.ProductName
-	}
-	if req.Quantity != nil {
-		order.Quantity = *req.Quantity
-	}
-	if req.Amount != nil {
-		order.Amount = *req.Amount
-	}
-	if req.Status != nil {
-		order.Status = *req.Status
+// publish adds event to the outbox in the transaction of ctx, when the service has a database
+func (s *orderService) publish(ctx context.Context, event outbox.Event) error {
+	if s.outbox == nil {
+		return nil
 	}
+	return s.outbox.Add(ctx, event)
+}
 
-	if err := s.repo.Update(ctx, order); err != nil {
-		return common.HandleRepositoryError(err, "Order not found", common.ErrorCodeNotFound, "Failed to update order")
+func (s *orderService) Update(ctx context.Context, id string, req *dto.UpdateOrderRequest, version int64) (int64, error) {
+	var newVersion int64
+	// The order is read again on every attempt, so a retry applies req to the current row
+	err := s.inTransaction(ctx, "order.update", func(ctx context.Context) error {
+		// Check if order exists
+		order, err := s.repo.FindByID(ctx, id)
+		if err != nil {
+			return common.HandleRepositoryError(err, "Order not found", common.ErrorCodeNotFound, "Failed to get order")
+		}
+		if err := common.CheckVersion(order.Version, version); err != nil {
+			return err
+		}
+
+		// Update fields
+		if req.ProductName != "" {
+			order.ProductName = req.ProductName
+		}
+		if req.Quantity != nil {
+			order.Quantity = *req.Quantity
+		}
+		if req.Amount != nil {
+			order.Amount = *req.Amount
+		}
+		if req.Status != nil {
+			order.Status = *req.Status
+		}
+
+		if err := s.repo.Update(ctx, order); err != nil {
+			return common.HandleRepositoryError(err, "Order not found", common.ErrorCodeNotFound, "Failed to update order")
+		}
+
+		newVersion = order.Version
+		return nil
+	})
+	if err != nil {
+		return 0, common.HandleTransactionError(err, "Failed to update order")
 	}
+	return newVersion, nil
+}
 
-	return nil
+func (s *orderService) Delete(ctx context.Context, id string, version int64) error {
+	err := s.inTransaction(ctx, "order.delete", func(ctx context.Context) error {
+		// Check if order exists
+		order, err := s.repo.FindByID(ctx, id)
+		if err != nil {
+			return common.HandleRepositoryError(err, "Order not found", common.ErrorCodeNotFound, "Failed to get order")
+		}
+		if err := common.CheckVersion(order.Version, version); err != nil {
+			return err
+		}
+
+		if err := s.repo.Delete(ctx, id, version); err != nil {
+			return common.HandleRepositoryError(err, "Order not found", common.ErrorCodeNotFound, "Failed to delete order")
+		}
+
+		return nil
+	})
+	return common.HandleTransactionError(err, "Failed to delete order")
 }
 
-func (s *orderService) Delete(ctx context.Context, id string) error {
-	// Check if order exists
-	_, err := s.repo.FindByID(ctx, id)
+func (s *orderService) Restore(ctx context.Context, id string) (*dto.OrderResponse, error) {
+	var order *entity.Order
+	err := s.inTransaction(ctx, "order.restore", func(ctx context.Context) error {
+		found, err := s.repo.FindByIDWithDeleted(ctx, id)
+		if err != nil {
+			return common.HandleRepositoryError(err, "Order not found", common.ErrorCodeNotFound, "Failed to get order")
+		}
+		if found.DeletedAt.Valid {
+			// Orders of a deleted user come back by restoring the user
+			if err := s.verifyUserExists(ctx, found.UserID); err != nil {
+				return err
+			}
+			if err := s.repo.Restore(ctx, id); err != nil {
+				return common.HandleRepositoryError(err, "", "", "Failed to restore order")
+			}
+			if found, err = s.repo.FindByID(ctx, id); err != nil {
+				return common.HandleRepositoryError(err, "Order not found", common.ErrorCodeNotFound, "Failed to get order")
+			}
+		}
+		order = found
+		return nil
+	})
 	if err != nil {
-		return common.HandleRepositoryError(err, "Order not found", common.ErrorCodeNotFound, "Failed to get order")
+		return nil, common.HandleTransactionError(err, "Failed to restore order")
 	}
 
-	if err := s.repo.Delete(ctx, id); err != nil {
-		return common.HandleRepositoryError(err, "Order not found", common.ErrorCodeNotFound, "Failed to delete order")
+	return s.toOrderResponse(ctx, order)
+}
+
+func (s *orderService) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := s.repo.DeleteByUserID(ctx, userID); err != nil {
+		return common.HandleRepositoryError(err, "", "", "Failed to delete orders of user")
 	}
+	return nil
+}
 
+func (s *orderService) RestoreByUserID(ctx context.Context, userID string, deletedSince time.Time) error {
+	if _, err := s.repo.RestoreByUserID(ctx, userID, deletedSince); err != nil {
+		return common.HandleRepositoryError(err, "", "", "Failed to restore orders of user")
+	}
 	return nil
 }
 
@@ -146,11 +248,14 @@ func (s *orderService) GetByID(ctx context.Context, id string) (*dto.OrderRespon
 func (s *orderService) GetAll(ctx context.Context, req *dto.OrderPagingRequest) (*dto.OrderPagingResponse, error) {
 	// Set defaults using common helper
 	req.Page, req.Limit = common.ValidatePagination(req.Page, req.Limit, common.DefaultPaginationLimit)
+	if req.Cursor != nil {
+		return s.getPage(ctx, req)
+	}
 
 	// Get orders with filters
-	orders, total, err := s.repo.FindAllWithFilters(ctx, req.UserID, req.ProductName, req.Status, req.Page, req.Limit)
+	orders, total, err := s.repo.FindAllWithFilters(ctx, req.UserID, req.ProductName, req.Status, req.IncludeDeleted, req.List, req.Page, req.Limit)
 	if err != nil {
-		return nil, common.NewServiceError(err, "Failed to get orders", common.ErrorCodeInternalError)
+		return nil, common.HandleRepositoryError(err, "", "", "Failed to get orders")
 	}
 
 	// Convert to response using common helper
@@ -168,6 +273,31 @@ func (s *orderService) GetAll(ctx context.Context, req *dto.OrderPagingRequest)
 	}, nil
 }
 
+// getPage is GetAll with cursor pagination
+func (s *orderService) getPage(ctx context.Context, req *dto.OrderPagingRequest) (*dto.OrderPagingResponse, error) {
+	orders, page, total, err := s.repo.FindPageWithFilters(ctx, req.UserID, req.ProductName, req.Status, req.IncludeDeleted, req.List, *req.Cursor, req.Limit)
+	if errors.Is(err, store.ErrInvalidCursor) {
+		return nil, common.NewServiceError(err, "Invalid cursor", common.ErrorCodeBadRequest)
+	}
+	if err != nil {
+		return nil, common.HandleRepositoryError(err, "", "", "Failed to get orders")
+	}
+
+	orderResponses, err := s.convertOrdersToResponses(ctx, orders)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.OrderPagingResponse{
+		Data:       orderResponses,
+		Limit:      req.Limit,
+		Total:      total,
+		TotalPages: common.CalculateTotalPages(total, req.Limit),
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}, nil
+}
+
 func (s *orderService) GetByUserID(ctx context.Context, userID string, page, limit int) (*dto.OrderPagingResponse, error) {
 	// Set defaults using common helper
 	page, limit = common.ValidatePagination(page, limit, common.DefaultPaginationLimit)
@@ -180,7 +310,7 @@ func (s *orderService) GetByUserID(ctx context.Context, userID string, page, lim
 	// Get orders by user ID
 	orders, total, err := s.repo.FindByUserID(ctx, userID, page, limit)
 	if err != nil {
-		return nil, common.NewServiceError(err, "Failed to get orders", common.ErrorCodeInternalError)
+		return nil, common.HandleRepositoryError(err, "", "", "Failed to get orders")
 	}
 
 	// Convert to response using common helper
@@ -209,9 +339,14 @@ func (s *orderService) toOrderResponse(ctx context.Context, order *entity.Order)
 		Amount:      order.Amount,
 		Status:      order.Status,
 		StatusText:  s.getStatusText(order.Status),
+		Version:     order.Version,
 		CreatedAt:   order.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:   order.UpdatedAt.Format(time.RFC3339),
 	}
+	if order.DeletedAt.Valid {
+		deletedAt := order.DeletedAt.Time.Format(time.RFC3339)
+		response.DeletedAt = &deletedAt
+	}
 
 	// Populate user information using type-safe inter-module interface
 	// This is an example of inter-module communication: Order module calls User module
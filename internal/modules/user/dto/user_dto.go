@@ -1,5 +1,12 @@
 package dto
 
+import (
+	"time"
+
+	"llm-aggregator/internal/entity"
+	"llm-aggregator/internal/store"
+)
+
 type CreateUserRequest struct {
 	Name  string `json:"name" binding:"required,min=1,max=255" validate:"required,min=1,max=255"`
 	Email string `json:"email" binding:"required,email" validate:"required,email"`
@@ -16,8 +23,20 @@ type UserResponse struct {
 	Name      string `json:"name"`
 	Email     string `json:"email"`
 	Status    int    `json:"status"`
+	Version   int64  `json:"version"` // Also sent as the ETag header; send it back in If-Match to update
 	CreatedAt string `json:"createdAt"`
 	UpdatedAt string `json:"updatedAt"`
+
+	DeletedAt *string `json:"deletedAt,omitempty"` // Set on soft-deleted users (listed with includeDeleted=true)
+}
+
+// UserDeactivatedEvent is the payload of the user.deactivated outbox event
+type UserDeactivatedEvent struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email"`
+	Version       int64     `json:"version"`
+	DeactivatedAt time.Time `json:"deactivatedAt"`
 }
 
 type PagingRequest struct {
@@ -25,6 +44,26 @@ type PagingRequest struct {
 	Limit int    `form:"limit" binding:"omitempty,min=1,max=100" validate:"omitempty,min=1,max=100"`
 	Name  string `form:"name" binding:"omitempty" validate:"omitempty"`
 	Email string `form:"email" binding:"omitempty" validate:"omitempty"`
+
+	IncludeDeleted bool `form:"includeDeleted"` // Also list soft-deleted users (admin)
+
+	// Cursor switches to cursor pagination: empty for the first page, then nextCursor or
+	// prevCursor of the previous response. Cannot be combined with page.
+	Cursor *string `form:"cursor"`
+
+	// List holds the filter[...] and sort parameters, parsed with UserListFields by the handler
+	List *store.ListQuery `form:"-"`
+}
+
+// UserListFields are the fields GET /users can be filtered (filter[...]) and sorted (sort) by
+var UserListFields = store.Fields{
+	"id":        {Column: entity.Column.ID, Type: store.FieldString},
+	"name":      {Column: entity.Column.Name, Type: store.FieldString},
+	"email":     {Column: entity.Column.Email, Type: store.FieldString},
+	"status":    {Column: entity.Column.Status, Type: store.FieldInt},
+	"version":   {Column: entity.Column.Version, Type: store.FieldInt},
+	"createdAt": {Column: entity.Column.CreatedAt, Type: store.FieldTime},
+	"updatedAt": {Column: entity.Column.UpdatedAt, Type: store.FieldTime},
 }
 
 // UserPagingResponse is a pagination response specific to User module
@@ -32,6 +71,8 @@ type UserPagingResponse struct {
 	Data       []UserResponse `json:"data"`
 	Page       int            `json:"page"`
 	Limit      int            `json:"limit"`
-	Total      int64          `json:"total"`
+	Total      int64          `json:"total"` // Cursor pagination: first page only, 0 on the others
 	TotalPages int            `json:"totalPages"`
+	NextCursor string         `json:"nextCursor,omitempty"` // Cursor pagination only
+	PrevCursor string         `json:"prevCursor,omitempty"` // Cursor pagination only
 }
@@ -7,6 +7,7 @@ import (
 	"llm-aggregator/internal/modules/user/dto"
 	"llm-aggregator/internal/modules/user/service"
 	"llm-aggregator/internal/modules/user/validator"
+	"llm-aggregator/internal/store"
 )
 
 type UserHandler struct {
@@ -61,6 +62,7 @@ func (h *UserHandler) Create(c *gin.Context) {
 // @Produce     json
 // @Param       id   path     string true "User ID"
 // @Success     200  {object} common.SuccessResponseDoc{data=dto.UserResponse}
+// @Header      200  {string} ETag "User version, to send in If-Match"
 // @Failure     404  {object} common.ErrorResponseDoc "Not Found - Error code: USER_NOT_FOUND"
 // @Failure     500  {object} common.ErrorResponseDoc "Internal Server Error - Error code: INTERNAL_ERROR"
 // @Router      /users/{id} [get]
@@ -79,6 +81,7 @@ func (h *UserHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	common.SetETag(c, user.Version)
 	common.RespondSuccess(c, user)
 }
 
@@ -91,6 +94,10 @@ func (h *UserHandler) GetByID(c *gin.Context) {
 // @Param       limit query    int    false "Items per page" default(20)
 // @Param       name  query    string false "Filter by name"
 // @Param       email query    string false "Filter by email"
+// @Param       includeDeleted query bool false "Also list soft-deleted users"
+// @Param       cursor query    string false "Cursor pagination: empty for the first page, then pagination.nextCursor or prevCursor; not with page"
+// @Param       filter[status][in] query string false "Filter by field and operator: filter[<field>][eq|ne|gt|gte|lt|lte|in|like]=<value>, e.g. filter[createdAt][gte]=2026-01-01"
+// @Param       sort  query    string false "Sort fields, descending with -, e.g. -createdAt,name; not with cursor"
 // @Success     200   {object} common.SuccessResponseWithPaginationDoc{data=[]dto.UserResponse}
 // @Failure     400   {object} common.ErrorResponseDoc "Bad Request - Possible error codes: BAD_REQUEST, VALIDATION_ERROR"
 // @Failure     500   {object} common.ErrorResponseDoc "Internal Server Error - Error code: INTERNAL_ERROR"
@@ -103,6 +110,12 @@ func (h *UserHandler) GetAll(c *gin.Context) {
 		common.RespondBadRequest(c, err.Error())
 		return
 	}
+	list, err := store.ParseListQuery(c.Request.URL.Query(), dto.UserListFields)
+	if err != nil {
+		common.RespondBadRequest(c, err.Error())
+		return
+	}
+	req.List = list
 
 	if err := h.validator.ValidatePaging(&req); err != nil {
 		common.RespondBadRequest(c, err.Error())
@@ -115,6 +128,16 @@ func (h *UserHandler) GetAll(c *gin.Context) {
 		return
 	}
 
+	if req.Cursor != nil {
+		// The total is counted on the first page only
+		var total *int64
+		if *req.Cursor == "" {
+			total = &result.Total
+		}
+		common.RespondSuccessWithCursor(c, result.Data, result.Limit, total, result.NextCursor, result.PrevCursor)
+		return
+	}
+
 	// Use pagination helper for cleaner response
 	common.RespondSuccessWithPagination(c, result.Data, result.Page, result.Limit, result.Total)
 }
@@ -124,12 +147,15 @@ func (h *UserHandler) GetAll(c *gin.Context) {
 // @Tags        users
 // @Accept      json
 // @Produce     json
-// @Param       id   path     string true "User ID"
-// @Param       user body     dto.UpdateUserRequest true "User data"
-// @Success     200  {object} common.SimpleSuccessResponseDoc
-// @Failure     400  {object} common.ErrorResponseDoc "Bad Request - Possible error codes: BAD_REQUEST, VALIDATION_ERROR, EMAIL_EXISTS"
-// @Failure     404  {object} common.ErrorResponseDoc "Not Found - Error code: USER_NOT_FOUND"
-// @Failure     500  {object} common.ErrorResponseDoc "Internal Server Error - Error code: INTERNAL_ERROR"
+// @Param       id       path     string                true  "User ID"
+// @Param       If-Match header   string                false "ETag from GET; fails with VERSION_CONFLICT when the user changed since"
+// @Param       user     body     dto.UpdateUserRequest true  "User data"
+// @Success     200      {object} common.SimpleSuccessResponseDoc
+// @Header      200      {string} ETag "New user version"
+// @Failure     400      {object} common.ErrorResponseDoc "Bad Request - Possible error codes: BAD_REQUEST, VALIDATION_ERROR, EMAIL_EXISTS"
+// @Failure     404      {object} common.ErrorResponseDoc "Not Found - Error code: USER_NOT_FOUND"
+// @Failure     409      {object} common.ErrorResponseDoc "Conflict - Error code: VERSION_CONFLICT"
+// @Failure     500      {object} common.ErrorResponseDoc "Internal Server Error - Error code: INTERNAL_ERROR"
 // @Router      /users/{id} [put]
 func (h *UserHandler) Update(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -151,12 +177,19 @@ func (h *UserHandler) Update(c *gin.Context) {
 		return
 	}
 
-	err := h.service.Update(ctx, id, &req)
+	version, err := common.IfMatchVersion(c)
+	if err != nil {
+		common.RespondBadRequest(c, err.Error())
+		return
+	}
+
+	newVersion, err := h.service.Update(ctx, id, &req, version)
 	if err != nil {
 		common.RespondServiceError(c, err)
 		return
 	}
 
+	common.SetETag(c, newVersion)
 	common.RespondSuccess(c, nil)
 }
 
@@ -165,10 +198,12 @@ func (h *UserHandler) Update(c *gin.Context) {
 // @Tags        users
 // @Accept      json
 // @Produce     json
-// @Param       id   path     string true "User ID"
-// @Success     200  {object} common.SimpleSuccessResponseDoc
-// @Failure     404  {object} common.ErrorResponseDoc "Not Found - Error code: USER_NOT_FOUND"
-// @Failure     500  {object} common.ErrorResponseDoc "Internal Server Error - Error code: INTERNAL_ERROR"
+// @Param       id       path     string true  "User ID"
+// @Param       If-Match header   string false "ETag from GET; fails with VERSION_CONFLICT when the user changed since"
+// @Success     200      {object} common.SimpleSuccessResponseDoc
+// @Failure     404      {object} common.ErrorResponseDoc "Not Found - Error code: USER_NOT_FOUND"
+// @Failure     409      {object} common.ErrorResponseDoc "Conflict - Error code: VERSION_CONFLICT"
+// @Failure     500      {object} common.ErrorResponseDoc "Internal Server Error - Error code: INTERNAL_ERROR"
 // @Router      /users/{id} [delete]
 func (h *UserHandler) Delete(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -179,7 +214,13 @@ func (h *UserHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	err := h.service.Delete(ctx, id)
+	version, err := common.IfMatchVersion(c)
+	if err != nil {
+		common.RespondBadRequest(c, err.Error())
+		return
+	}
+
+	err = h.service.Delete(ctx, id, version)
 	if err != nil {
 		common.RespondServiceError(c, err)
 		return
@@ -187,3 +228,33 @@ func (h *UserHandler) Delete(c *gin.Context) {
 
 	common.RespondSuccess(c, nil)
 }
+
+// @Summary     Restore user
+// @Description Undo the soft delete of a user, together with the orders deleted with it
+// @Tags        users
+// @Accept      json
+// @Produce     json
+// @Param       id   path     string true "User ID"
+// @Success     200  {object} common.SuccessResponseDoc{data=dto.UserResponse}
+// @Header      200  {string} ETag "User version, to send in If-Match"
+// @Failure     404  {object} common.ErrorResponseDoc "Not Found - Error code: USER_NOT_FOUND"
+// @Failure     500  {object} common.ErrorResponseDoc "Internal Server Error - Error code: INTERNAL_ERROR"
+// @Router      /users/{id}/restore [post]
+func (h *UserHandler) Restore(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := c.Param("id")
+	if id == "" {
+		common.RespondBadRequest(c, "ID is required")
+		return
+	}
+
+	user, err := h.service.Restore(ctx, id)
+	if err != nil {
+		common.RespondServiceError(c, err)
+		return
+	}
+
+	common.SetETag(c, user.Version)
+	common.RespondSuccess(c, user)
+}
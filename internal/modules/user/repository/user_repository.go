@@ -14,12 +14,20 @@ import (
 type UserRepository interface {
 	Create(ctx context.Context, user *entity.User) error
 	Update(ctx context.Context, user *entity.User) error
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string, version int64) error
 	FindByID(ctx context.Context, id string) (*entity.User, error)
+	FindByIDWithDeleted(ctx context.Context, id string) (*entity.User, error)
+	Restore(ctx context.Context, id string) error
 	FindByEmail(ctx context.Context, email string) (*entity.User, error)
 	FindAll(ctx context.Context, query *store.Query[entity.User]) ([]entity.User, error)
 	Count(ctx context.Context, query *store.Query[entity.User]) (int64, error)
-	FindAllWithFilters(ctx context.Context, name, email string, page, limit int) ([]entity.User, int64, error)
+	// FindAllWithFilters also applies list (filter[...] and sort, may be nil); without sort,
+	// users are listed newest first
+	FindAllWithFilters(ctx context.Context, name, email string, includeDeleted bool, list *store.ListQuery, page, limit int) ([]entity.User, int64, error)
+	// FindPageWithFilters is FindAllWithFilters with cursor pagination; it returns
+	// store.ErrInvalidCursor when cursor cannot be used. list must not sort. The total is
+	// counted on the first page (empty cursor) only, and 0 on the others.
+	FindPageWithFilters(ctx context.Context, name, email string, includeDeleted bool, list *store.ListQuery, cursor string, limit int) ([]entity.User, *store.CursorPage, int64, error)
 	WithTx(tx *gorm.DB) UserRepository
 }
 
@@ -36,29 +44,49 @@ func (r *userRepository) WithTx(tx *gorm.DB) UserRepository {
 }
 
 func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
-	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
+	if err := common.DBFromContext(ctx, r.db).Create(user).Error; err != nil {
 		return common.WrapError(err, "failed to create user")
 	}
 	return nil
 }
 
+// Update writes user if it still has the version it was read with, and increments the version.
+// Returns common.ErrVersionConflict when the user was changed in the meantime.
 func (r *userRepository) Update(ctx context.Context, user *entity.User) error {
-	result := r.db.WithContext(ctx).Model(&entity.User{}).Where(entity.Column.ID+" = ?", user.ID).Updates(user)
+	db := common.DBFromContext(ctx, r.db)
+	version := user.Version
+	user.Version = version + 1
+	// Select writes zero values too, e.g. status 0 to deactivate the user
+	result := db.Model(&entity.User{}).
+		Where(entity.Column.ID+" = ? AND "+entity.Column.Version+" = ?", user.ID, version).
+		Select(entity.Column.Name, entity.Column.Email, entity.Column.Status, entity.Column.Version, entity.Column.UpdatedAt).
+		Updates(user)
 	if result.Error != nil {
+		user.Version = version
 		return common.WrapError(result.Error, "failed to update user")
 	}
 	if result.RowsAffected == 0 {
-		return common.ErrNotFound
+		user.Version = version
+		return common.VersionMissError(db, &entity.User{}, user.ID)
 	}
 	return nil
 }
 
-func (r *userRepository) Delete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).Delete(&entity.User{}, entity.Column.ID+" = ?", id)
+// Delete soft-deletes the user id; a non-zero version makes the delete conditional like Update
+func (r *userRepository) Delete(ctx context.Context, id string, version int64) error {
+	db := common.DBFromContext(ctx, r.db)
+	query := db.Where(entity.Column.ID+" = ?", id)
+	if version != 0 {
+		query = query.Where(entity.Column.Version+" = ?", version)
+	}
+	result := query.Delete(&entity.User{})
 	if result.Error != nil {
 		return common.WrapError(result.Error, "failed to delete user")
 	}
 	if result.RowsAffected == 0 {
+		if version != 0 {
+			return common.VersionMissError(db, &entity.User{}, id)
+		}
 		return common.ErrNotFound
 	}
 	return nil
@@ -66,7 +94,19 @@ func (r *userRepository) Delete(ctx context.Context, id string) error {
 
 func (r *userRepository) FindByID(ctx context.Context, id string) (*entity.User, error) {
 	var user entity.User
-	if err := r.db.WithContext(ctx).Where(entity.Column.ID+" = ?", id).First(&user).Error; err != nil {
+	if err := common.DBFromContext(ctx, r.db).Where(entity.Column.ID+" = ?", id).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrNotFound
+		}
+		return nil, common.WrapError(err, "failed to find user by id")
+	}
+	return &user, nil
+}
+
+// FindByIDWithDeleted finds the user id, soft-deleted or not
+func (r *userRepository) FindByIDWithDeleted(ctx context.Context, id string) (*entity.User, error) {
+	var user entity.User
+	if err := common.DBFromContext(ctx, r.db).Unscoped().Where(entity.Column.ID+" = ?", id).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, common.ErrNotFound
 		}
@@ -75,9 +115,24 @@ func (r *userRepository) FindByID(ctx context.Context, id string) (*entity.User,
 	return &user, nil
 }
 
+// Restore undoes the soft delete of the user id and increments its version.
+// Restoring a user that is not deleted does nothing.
+func (r *userRepository) Restore(ctx context.Context, id string) error {
+	result := common.DBFromContext(ctx, r.db).Unscoped().Model(&entity.User{}).
+		Where(entity.Column.ID+" = ? AND "+entity.Column.DeletedAt+" IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			entity.Column.DeletedAt: nil,
+			entity.Column.Version:   gorm.Expr(entity.Column.Version + " + 1"),
+		})
+	if result.Error != nil {
+		return common.WrapError(result.Error, "failed to restore user")
+	}
+	return nil
+}
+
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
 	var user entity.User
-	if err := r.db.WithContext(ctx).Where(entity.Column.Email+" = ?", email).First(&user).Error; err != nil {
+	if err := common.DBFromContext(ctx, r.db).Where(entity.Column.Email+" = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, common.ErrNotFound
 		}
@@ -102,17 +157,10 @@ func (r *userRepository) Count(ctx context.Context, query *store.Query[entity.Us
 	return count, nil
 }
 
-func (r *userRepository) FindAllWithFilters(ctx context.Context, name, email string, page, limit int) ([]entity.User, int64, error) {
-	// Build query using fluent query builder
-	query := store.NewQuery[entity.User](r.db).WithContext(ctx)
-
-	if name != "" {
-		query = query.Like(entity.Column.Name, name)
-	}
-	if email != "" {
-		query = query.Like(entity.Column.Email, email)
-	}
+func (r *userRepository) FindAllWithFilters(ctx context.Context, name, email string, includeDeleted bool, list *store.ListQuery, page, limit int) ([]entity.User, int64, error) {
+	query := r.filtered(ctx, name, email, includeDeleted, list)
 
+	// Breaks ties of the sort of list, if any
 	query = query.OrderBy(entity.Column.CreatedAt, entity.OrderDESC)
 	query = query.Page(page, limit)
 
@@ -130,3 +178,39 @@ func (r *userRepository) FindAllWithFilters(ctx context.Context, name, email str
 
 	return users, total, nil
 }
+
+func (r *userRepository) FindPageWithFilters(ctx context.Context, name, email string, includeDeleted bool, list *store.ListQuery, cursor string, limit int) ([]entity.User, *store.CursorPage, int64, error) {
+	// Count on the first page only: a COUNT scans every matching row, which the keyset query
+	// of the following pages avoids
+	var total int64
+	if cursor == "" {
+		var err error
+		if total, err = r.filtered(ctx, name, email, includeDeleted, list).Count(); err != nil {
+			return nil, nil, 0, common.WrapError(err, "failed to count users")
+		}
+	}
+
+	var users []entity.User
+	page, err := r.filtered(ctx, name, email, includeDeleted, list).
+		Keyset(entity.Column.CreatedAt, entity.OrderDESC, cursor, limit).
+		FindPage(&users)
+	if err != nil {
+		return nil, nil, 0, common.WrapError(err, "failed to find users")
+	}
+
+	return users, page, total, nil
+}
+
+// filtered returns a query for the users matching the list filters
+func (r *userRepository) filtered(ctx context.Context, name, email string, includeDeleted bool, list *store.ListQuery) *store.Query[entity.User] {
+	// Build query using fluent query builder
+	query := store.NewQuery[entity.User](common.DBFromContext(ctx, r.db)).Unscoped(includeDeleted)
+
+	if name != "" {
+		query = query.Like(entity.Column.Name, name)
+	}
+	if email != "" {
+		query = query.Like(entity.Column.Email, email)
+	}
+	return query.Apply(list)
+}
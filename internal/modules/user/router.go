@@ -19,7 +19,8 @@ import (
 func RegisterRoutes(r gin.IRouter, db *gorm.DB, container *container.ModuleContainer) service.UserService {
 	// Initialize dependencies
 	userRepo := repository.NewUserRepository(db)
-	baseUserService := service.NewUserService(userRepo)
+	// Pass container and db so users are deleted and restored together with their orders
+	baseUserService := service.NewUserServiceWithDB(userRepo, container, db)
 	// Wrap with metrics instrumentation
 	userService := service.NewInstrumentedUserService(baseUserService)
 	userValidator := validator.NewUserValidator()
@@ -38,6 +39,7 @@ func RegisterRoutes(r gin.IRouter, db *gorm.DB, container *container.ModuleConta
 		users.GET("/:id", userHandler.GetByID)
 		users.PUT("/:id", userHandler.Update)
 		users.DELETE("/:id", userHandler.Delete)
+		users.POST("/:id/restore", userHandler.Restore)
 	}
 
 	// Return the service so it can be registered in the container
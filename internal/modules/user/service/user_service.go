@@ -3,26 +3,39 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"llm-aggregator/internal/common"
+	"llm-aggregator/internal/container"
 	"llm-aggregator/internal/entity"
 	"llm-aggregator/internal/modules/user/dto"
 	"llm-aggregator/internal/modules/user/repository"
+	"llm-aggregator/internal/outbox"
+	"llm-aggregator/internal/store"
 )
 
 type UserService interface {
 	Create(ctx context.Context, req *dto.CreateUserRequest) (*dto.UserResponse, error)
-	Update(ctx context.Context, id string, req *dto.UpdateUserRequest) error
-	Delete(ctx context.Context, id string) error
+	// Update applies req and returns the new version. A non-zero version (from If-Match) must
+	// match the current one, otherwise the update fails with VERSION_CONFLICT.
+	Update(ctx context.Context, id string, req *dto.UpdateUserRequest, version int64) (int64, error)
+	// Delete soft-deletes the user and its orders; a non-zero version must match like in Update
+	Delete(ctx context.Context, id string, version int64) error
+	// Restore undoes the soft delete of the user and of the orders deleted with it
+	Restore(ctx context.Context, id string) (*dto.UserResponse, error)
 	GetByID(ctx context.Context, id string) (*dto.UserResponse, error)
 	GetAll(ctx context.Context, req *dto.PagingRequest) (*dto.UserPagingResponse, error)
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo      repository.UserRepository
+	container *container.ModuleContainer
+	uow       *common.UnitOfWork
+	outbox    *outbox.Writer // user.deactivated events; nil without db
 }
 
 func NewUserService(repo repository.UserRepository) UserService {
@@ -31,6 +44,17 @@ func NewUserService(repo repository.UserRepository) UserService {
 	}
 }
 
+// NewUserServiceWithDB creates a user service that deletes and restores the orders of a user
+// (through the container) and writes its outbox events in the same transaction as the user
+func NewUserServiceWithDB(repo repository.UserRepository, container *container.ModuleContainer, db *gorm.DB) UserService {
+	return &userService{
+		repo:      repo,
+		container: container,
+		uow:       common.NewUnitOfWork(db),
+		outbox:    outbox.NewWriter(db),
+	}
+}
+
 func (s *userService) Create(ctx context.Context, req *dto.CreateUserRequest) (*dto.UserResponse, error) {
 	// Check if user with email already exists
 	existingUser, err := s.repo.FindByEmail(ctx, req.Email)
@@ -43,65 +67,153 @@ func (s *userService) Create(ctx context.Context, req *dto.CreateUserRequest) (*
 
 	// Create new user
 	user := &entity.User{
-		ID:     uuid.New().String(),
-		Name:   req.Name,
-		Email:  req.Email,
-		Status: 1,
+		ID:      uuid.New().String(),
+		Name:    req.Name,
+		Email:   req.Email,
+		Status:  1,
+		Version: 1,
 	}
 
 	if err := s.repo.Create(ctx, user); err != nil {
+		// A concurrent request may have created the same email after the check above
+		if common.ClassifyDBError(err) == common.DBErrorDuplicate {
+			return nil, common.NewServiceError(err, "User with this email already exists", common.ErrorCodeEmailExists)
+		}
 		return nil, common.HandleRepositoryError(err, "", "", "Failed to create user")
 	}
 
 	return s.toUserResponse(user), nil
 }
 
-func (s *userService) Update(ctx context.Context, id string, req *dto.UpdateUserRequest) error {
-	// Check if user exists
-	user, err := s.repo.FindByID(ctx, id)
-	if err != nil {
-		return common.HandleRepositoryError(err, "User not found", common.ErrorCodeUserNotFound, "Failed to get user")
-	}
+func (s *userService) Update(ctx context.Context, id string, req *dto.UpdateUserRequest, version int64) (int64, error) {
+	var newVersion int64
+	// The user is read again on every attempt, so a retry applies req to the current row
+	err := s.inTransaction(ctx, "user.update", func(ctx context.Context) error {
+		// Check if user exists
+		user, err := s.repo.FindByID(ctx, id)
+		if err != nil {
+			return common.HandleRepositoryError(err, "User not found", common.ErrorCodeUserNotFound, "Failed to get user")
+		}
+		if err := common.CheckVersion(user.Version, version); err != nil {
+			return err
+		}
+		wasActive := user.Status == 1
 
-	// Check email uniqueness if email is being updated
-	if req.Email != "" && req.Email != user.Email {
-		existingUser, err := s.repo.FindByEmail(ctx, req.Email)
-		if err != nil && !errors.Is(err, common.ErrNotFound) {
-			return common.HandleRepositoryError(err, "", "", "Failed to check email uniqueness")
+		// Check email uniqueness if email is being updated
+		if req.Email != "" && req.Email != user.Email {
+			existingUser, err := s.repo.FindByEmail(ctx, req.Email)
+			if err != nil && !errors.Is(err, common.ErrNotFound) {
+				return common.HandleRepositoryError(err, "", "", "Failed to check email uniqueness")
+			}
+			if existingUser != nil {
+				return common.NewServiceError(common.ErrInvalid, "Email already exists", common.ErrorCodeEmailExists)
+			}
+			user.Email = req.Email
 		}
-		if existingUser != nil {
-			return common.NewServiceError(common.ErrInvalid, "Email already exists", common.ErrorCodeEmailExists)
+
+		// Update fields
+		if req.Name != "" {
+			user.Name = req.Name
+		}
+		if req.Status != nil {
+			user.Status = *req.Status
 		}
-		user.Email = req.Email
-	}
 
-	// Update fields
-	if req.Name != "" {
-		user.Name = req.Name
-	}
-	if req.Status != nil {
-		user.Status = *req.Status
-	}
+		if err := s.repo.Update(ctx, user); err != nil {
+			if common.ClassifyDBError(err) == common.DBErrorDuplicate {
+				return common.NewServiceError(err, "Email already exists", common.ErrorCodeEmailExists)
+			}
+			return common.HandleRepositoryError(err, "User not found", common.ErrorCodeUserNotFound, "Failed to update user")
+		}
+		newVersion = user.Version
 
-	if err := s.repo.Update(ctx, user); err != nil {
-		return common.HandleRepositoryError(err, "User not found", common.ErrorCodeUserNotFound, "Failed to update user")
+		// Written in the transaction of the update; the version tells deactivations apart
+		// after a reactivation
+		if wasActive && user.Status == 0 && s.outbox != nil {
+			return s.outbox.Add(ctx, outbox.Event{
+				Type:          outbox.EventUserDeactivated,
+				AggregateType: "user",
+				AggregateID:   user.ID,
+				DedupeKey:     fmt.Sprintf("%s:%s:%d", outbox.EventUserDeactivated, user.ID, user.Version),
+				Payload: dto.UserDeactivatedEvent{
+					ID:            user.ID,
+					Name:          user.Name,
+					Email:         user.Email,
+					Version:       user.Version,
+					DeactivatedAt: time.Now(),
+				},
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, common.HandleTransactionError(err, "Failed to update user")
 	}
+	return newVersion, nil
+}
 
-	return nil
+func (s *userService) Delete(ctx context.Context, id string, version int64) error {
+	err := s.inTransaction(ctx, "user.delete", func(ctx context.Context) error {
+		// Check if user exists
+		user, err := s.repo.FindByID(ctx, id)
+		if err != nil {
+			return common.HandleRepositoryError(err, "User not found", common.ErrorCodeUserNotFound, "Failed to get user")
+		}
+		if err := common.CheckVersion(user.Version, version); err != nil {
+			return err
+		}
+
+		if err := s.repo.Delete(ctx, id, version); err != nil {
+			return common.HandleRepositoryError(err, "User not found", common.ErrorCodeUserNotFound, "Failed to delete user")
+		}
+
+		// The orders of the user are deleted with it, so none is left pointing at a hidden user
+		if s.container != nil && s.container.OrderService != nil {
+			return s.container.OrderService.DeleteByUserID(ctx, id)
+		}
+		return nil
+	})
+	return common.HandleTransactionError(err, "Failed to delete user")
 }
 
-func (s *userService) Delete(ctx context.Context, id string) error {
-	// Check if user exists
-	_, err := s.repo.FindByID(ctx, id)
+func (s *userService) Restore(ctx context.Context, id string) (*dto.UserResponse, error) {
+	var user *entity.User
+	err := s.inTransaction(ctx, "user.restore", func(ctx context.Context) error {
+		found, err := s.repo.FindByIDWithDeleted(ctx, id)
+		if err != nil {
+			return common.HandleRepositoryError(err, "User not found", common.ErrorCodeUserNotFound, "Failed to get user")
+		}
+		if found.DeletedAt.Valid {
+			if err := s.repo.Restore(ctx, id); err != nil {
+				return common.HandleRepositoryError(err, "", "", "Failed to restore user")
+			}
+			// Orders deleted with the user; orders deleted before it stay deleted
+			if s.container != nil && s.container.OrderService != nil {
+				if err := s.container.OrderService.RestoreByUserID(ctx, id, found.DeletedAt.Time); err != nil {
+					return err
+				}
+			}
+			if found, err = s.repo.FindByID(ctx, id); err != nil {
+				return common.HandleRepositoryError(err, "User not found", common.ErrorCodeUserNotFound, "Failed to get user")
+			}
+		}
+		user = found
+		return nil
+	})
 	if err != nil {
-		return common.HandleRepositoryError(err, "User not found", common.ErrorCodeUserNotFound, "Failed to get user")
+		return nil, common.HandleTransactionError(err, "Failed to restore user")
 	}
 
-	if err := s.repo.Delete(ctx, id); err != nil {
-		return common.HandleRepositoryError(err, "User not found", common.ErrorCodeUserNotFound, "Failed to delete user")
-	}
+	return s.toUserResponse(user), nil
+}
 
-	return nil
+// inTransaction runs fn in a unit of work retried on deadlocks and lock wait timeouts,
+// or directly when the service has no database
+func (s *userService) inTransaction(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	if s.uow == nil {
+		return fn(ctx)
+	}
+	return s.uow.DoWithRetry(ctx, operation, fn)
 }
 
 func (s *userService) GetByID(ctx context.Context, id string) (*dto.UserResponse, error) {
@@ -116,9 +228,12 @@ func (s *userService) GetByID(ctx context.Context, id string) (*dto.UserResponse
 func (s *userService) GetAll(ctx context.Context, req *dto.PagingRequest) (*dto.UserPagingResponse, error) {
 	// Set defaults using common helper
 	req.Page, req.Limit = common.ValidatePagination(req.Page, req.Limit, common.DefaultPaginationLimitUser)
+	if req.Cursor != nil {
+		return s.getPage(ctx, req)
+	}
 
 	// Get users with filters using repository method
-	users, total, err := s.repo.FindAllWithFilters(ctx, req.Name, req.Email, req.Page, req.Limit)
+	users, total, err := s.repo.FindAllWithFilters(ctx, req.Name, req.Email, req.IncludeDeleted, req.List, req.Page, req.Limit)
 	if err != nil {
 		return nil, common.HandleRepositoryError(err, "", "", "Failed to get users")
 	}
@@ -135,6 +250,26 @@ func (s *userService) GetAll(ctx context.Context, req *dto.PagingRequest) (*dto.
 	}, nil
 }
 
+// getPage is GetAll with cursor pagination
+func (s *userService) getPage(ctx context.Context, req *dto.PagingRequest) (*dto.UserPagingResponse, error) {
+	users, page, total, err := s.repo.FindPageWithFilters(ctx, req.Name, req.Email, req.IncludeDeleted, req.List, *req.Cursor, req.Limit)
+	if errors.Is(err, store.ErrInvalidCursor) {
+		return nil, common.NewServiceError(err, "Invalid cursor", common.ErrorCodeBadRequest)
+	}
+	if err != nil {
+		return nil, common.HandleRepositoryError(err, "", "", "Failed to get users")
+	}
+
+	return &dto.UserPagingResponse{
+		Data:       s.convertUsersToResponses(users),
+		Limit:      req.Limit,
+		Total:      total,
+		TotalPages: common.CalculateTotalPages(total, req.Limit),
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}, nil
+}
+
 // convertUsersToResponses converts a slice of User entities to UserResponse DTOs.
 // This helper method eliminates code duplication.
 func (s *userService) convertUsersToResponses(users []entity.User) []dto.UserResponse {
@@ -146,12 +281,18 @@ func (s *userService) convertUsersToResponses(users []entity.User) []dto.UserRes
 }
 
 func (s *userService) toUserResponse(user *entity.User) *dto.UserResponse {
-	return &dto.UserResponse{
+	response := &dto.UserResponse{
 		ID:        user.ID,
 		Name:      user.Name,
 		Email:     user.Email,
 		Status:    user.Status,
+		Version:   user.Version,
 		CreatedAt: user.CreatedAt.Format(time.RFC3339),
 		UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
 	}
+	if user.DeletedAt.Valid {
+		deletedAt := user.DeletedAt.Time.Format(time.RFC3339)
+		response.DeletedAt = &deletedAt
+	}
+	return response
 }
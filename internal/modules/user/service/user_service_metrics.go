@@ -40,9 +40,9 @@ func (s *instrumentedUserService) Create(ctx context.Context, req *dto.CreateUse
 	return result, err
 }
 
-func (s *instrumentedUserService) Update(ctx context.Context, id string, req *dto.UpdateUserRequest) error {
+func (s *instrumentedUserService) Update(ctx context.Context, id string, req *dto.UpdateUserRequest, version int64) (int64, error) {
 	start := time.Now()
-	err := s.service.Update(ctx, id, req)
+	newVersion, err := s.service.Update(ctx, id, req, version)
 	duration := time.Since(start).Seconds()
 
 	metrics.BusinessOperationsTotal.WithLabelValues("update", "user").Inc()
@@ -56,12 +56,12 @@ func (s *instrumentedUserService) Update(ctx context.Context, id string, req *dt
 		metrics.BusinessErrorsTotal.WithLabelValues("update", "user", errorCode).Inc()
 	}
 
-	return err
+	return newVersion, err
 }
 
-func (s *instrumentedUserService) Delete(ctx context.Context, id string) error {
+func (s *instrumentedUserService) Delete(ctx context.Context, id string, version int64) error {
 	start := time.Now()
-	err := s.service.Delete(ctx, id)
+	err := s.service.Delete(ctx, id, version)
 	duration := time.Since(start).Seconds()
 
 	metrics.BusinessOperationsTotal.WithLabelValues("delete", "user").Inc()
@@ -78,6 +78,25 @@ func (s *instrumentedUserService) Delete(ctx context.Context, id string) error {
 	return err
 }
 
+func (s *instrumentedUserService) Restore(ctx context.Context, id string) (*dto.UserResponse, error) {
+	start := time.Now()
+	result, err := s.service.Restore(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	metrics.BusinessOperationsTotal.WithLabelValues("restore", "user").Inc()
+	metrics.BusinessOperationDuration.WithLabelValues("restore", "user").Observe(duration)
+
+	if err != nil {
+		errorCode := "unknown"
+		if svcErr, ok := err.(*common.ServiceError); ok {
+			errorCode = svcErr.Code
+		}
+		metrics.BusinessErrorsTotal.WithLabelValues("restore", "user", errorCode).Inc()
+	}
+
+	return result, err
+}
+
 func (s *instrumentedUserService) GetByID(ctx context.Context, id string) (*dto.UserResponse, error) {
 	start := time.Now()
 	result, err := s.service.GetByID(ctx, id)
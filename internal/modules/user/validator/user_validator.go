@@ -34,9 +34,15 @@ func (uv *UserValidator) ValidatePaging(req *dto.PagingRequest) error {
 	if err := uv.validate.Struct(req); err != nil {
 		return fmt.Errorf("validation failed: %w", err)
 	}
+	if req.Cursor != nil && req.Page > 0 {
+		return fmt.Errorf("validation failed: page cannot be combined with cursor")
+	}
+	if req.Cursor != nil && req.List != nil && len(req.List.Sorts) > 0 {
+		return fmt.Errorf("validation failed: sort cannot be combined with cursor")
+	}
 
 	// Set defaults
-	if req.Page < 1 {
+	if req.Cursor == nil && req.Page < 1 {
 		req.Page = 1
 	}
 	if req.Limit < 1 {
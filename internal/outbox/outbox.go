@@ -0,0 +1,81 @@
+// Package outbox implements the transactional outbox: services write domain events with Writer
+// in the transaction of the change they describe, and the Relay delivers them to a Publisher
+// after commit, at least once.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"llm-aggregator/internal/common"
+	"llm-aggregator/internal/entity"
+)
+
+// Event types
+const (
+	EventOrderCreated    = "order.created"
+	EventUserDeactivated = "user.deactivated"
+)
+
+// Event is a domain event to deliver once its transaction commits
+type Event struct {
+	Type          string // e.g. EventOrderCreated
+	AggregateType string // e.g. "order"
+	AggregateID   string
+	// DedupeKey identifies the event: a second event with the same key is ignored, and
+	// consumers receive it to drop redeliveries (e.g. "order.created:<id>")
+	DedupeKey string
+	Payload   interface{} // Encoded as JSON
+}
+
+// Writer adds events to the outbox table
+type Writer struct {
+	db *gorm.DB
+}
+
+// NewWriter creates a writer on db
+func NewWriter(db *gorm.DB) *Writer {
+	return &Writer{db: db}
+}
+
+// Add writes event in the transaction carried by ctx, so it is delivered only if the
+// transaction commits. Call it inside UnitOfWork.Do with the change the event describes.
+//
+// Usage:
+//   err := uow.Do(ctx, func(ctx context.Context) error {
+//       if err := s.repo.Create(ctx, order); err != nil {
+//           return err
+//       }
+//       return s.outbox.Add(ctx, outbox.Event{Type: outbox.EventOrderCreated, ...})
+//   })
+func (w *Writer) Add(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event payload: %w", event.Type, err)
+	}
+
+	row := &entity.OutboxEvent{
+		ID:            uuid.New().String(),
+		EventType:     event.Type,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		DedupeKey:     event.DedupeKey,
+		Payload:       string(payload),
+		Status:        entity.OutboxStatusPending,
+		NextAttemptAt: time.Now(),
+	}
+	// An event already written with the same dedupe key (e.g. by a retried request) is kept
+	err = common.DBFromContext(ctx, w.db).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: entity.OutboxEventColumn.DedupeKey}}, DoNothing: true}).
+		Create(row).Error
+	if err != nil {
+		return fmt.Errorf("failed to write %s event: %w", event.Type, err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"llm-aggregator/internal/config"
+)
+
+// Message is the envelope of an event sent to a Publisher
+type Message struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateID   string          `json:"aggregateId"`
+	DedupeKey     string          `json:"dedupeKey"` // Same on every redelivery of the event
+	OccurredAt    time.Time       `json:"occurredAt"`
+	Attempt       int             `json:"attempt"` // 1 on the first delivery
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// Publisher delivers events to other systems.
+// The relay retries a message when Publish returns an error, so Publish may be called again
+// with a message it already delivered; consumers drop duplicates by DedupeKey.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// NewPublisher creates the publisher selected by cfg.Publisher, or returns nil for "none"
+func NewPublisher(cfg config.OutboxConfig) (Publisher, error) {
+	switch cfg.Publisher {
+	case "none":
+		return nil, nil
+	case "stdout":
+		return NewWriterPublisher(os.Stdout), nil
+	case "file":
+		return NewFilePublisher(cfg.FilePath)
+	case "webhook":
+		return NewWebhookPublisher(cfg.WebhookURL, cfg.WebhookSecret.Value(), time.Duration(cfg.WebhookTimeoutSeconds)*time.Second), nil
+	default:
+		return nil, fmt.Errorf("unknown outbox publisher %q", cfg.Publisher)
+	}
+}
+
+// WriterPublisher writes each message as a line of JSON, for local use
+type WriterPublisher struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewWriterPublisher creates a publisher writing to w (e.g. os.Stdout)
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+// NewFilePublisher creates a publisher appending to the file at path, created if needed
+func NewFilePublisher(path string) (*WriterPublisher, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox file: %w", err)
+	}
+	return &WriterPublisher{w: file, closer: file}, nil
+}
+
+// Publish implements Publisher
+func (p *WriterPublisher) Publish(_ context.Context, msg Message) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = p.w.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the file of a publisher created by NewFilePublisher
+func (p *WriterPublisher) Close() error {
+	if p.closer == nil {
+		return nil
+	}
+	return p.closer.Close()
+}
+
+// WebhookPublisher POSTs each message as JSON to a URL. Any 2xx response is a delivery;
+// other responses and network errors are retried.
+//
+// Requests carry the headers X-Event-ID, X-Event-Type, X-Event-Attempt and
+// Idempotency-Key (the dedupe key), and X-Outbox-Signature ("sha256=" + hex HMAC-SHA256 of
+// the body) when a secret is set.
+type WebhookPublisher struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhookPublisher creates a publisher posting to url; an empty secret sends unsigned requests
+func NewWebhookPublisher(url, secret string, timeout time.Duration) *WebhookPublisher {
+	return &WebhookPublisher{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Publish implements Publisher
+func (p *WebhookPublisher) Publish(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", msg.ID)
+	req.Header.Set("X-Event-Type", msg.Type)
+	req.Header.Set("X-Event-Attempt", strconv.Itoa(msg.Attempt))
+	req.Header.Set("Idempotency-Key", msg.DedupeKey)
+	if len(p.secret) > 0 {
+		mac := hmac.New(sha256.New, p.secret)
+		mac.Write(body)
+		req.Header.Set("X-Outbox-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Read part of the body for the error, and drain the rest so the connection is reused
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// The snippet may end inside a multi-byte rune
+		return fmt.Errorf("webhook responded %d: %s", resp.StatusCode, strings.ToValidUTF8(string(bytes.TrimSpace(snippet)), ""))
+	}
+	return nil
+}
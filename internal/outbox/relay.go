@@ -0,0 +1,302 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"llm-aggregator/internal/config"
+	"llm-aggregator/internal/database"
+	"llm-aggregator/internal/entity"
+	"llm-aggregator/internal/logger"
+	"llm-aggregator/internal/metrics"
+)
+
+const (
+	// ClaimLease is how long a relay owns the events it claimed. Events it has not
+	// delivered or rescheduled by then (e.g. the process crashed) are claimed again.
+	ClaimLease = 5 * time.Minute
+
+	// RetryBaseDelay is the delay before the second attempt; it doubles after each failure
+	RetryBaseDelay = time.Second
+
+	// CleanupInterval is how often delivered events older than the retention are deleted
+	CleanupInterval = time.Hour
+
+	// maxErrorLength bounds the last_error stored for a failed delivery
+	maxErrorLength = 1000
+)
+
+// Relay delivers the pending events of the outbox table to a Publisher.
+//
+// Several instances may run against the same database: each claims a batch of due events
+// by setting a claim token and pushing next_attempt_at past the lease, so a batch is
+// delivered by one instance at a time. An event is marked delivered only after Publish
+// succeeds, so a crash between the two redelivers it (at-least-once). Failed deliveries are
+// retried with exponential backoff until MaxAttempts, then the event is marked failed.
+type Relay struct {
+	db        *gorm.DB
+	publisher Publisher
+	cfg       config.OutboxConfig
+}
+
+// NewRelay creates a relay delivering the events of db to publisher
+func NewRelay(db *gorm.DB, publisher Publisher, cfg config.OutboxConfig) *Relay {
+	return &Relay{db: db, publisher: publisher, cfg: cfg}
+}
+
+// Run delivers due events every poll interval until ctx is cancelled, and deletes delivered
+// events older than the retention every CleanupInterval
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(r.cfg.PollIntervalMs) * time.Millisecond)
+	defer ticker.Stop()
+	var lastCleanup time.Time
+
+	for {
+		r.Poll(ctx)
+		if r.cfg.RetentionDays > 0 && time.Since(lastCleanup) >= CleanupInterval {
+			r.cleanup(ctx)
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll delivers due events, batch after batch, until none is left or ctx is cancelled
+func (r *Relay) Poll(ctx context.Context) {
+	log := logger.GetLogger()
+	for ctx.Err() == nil {
+		delivered, err := r.deliverBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error("Failed to relay outbox events", zap.Error(err))
+		}
+		if err != nil || delivered < r.cfg.BatchSize {
+			break
+		}
+	}
+	r.updatePendingGauge(ctx)
+}
+
+// deliverBatch claims up to BatchSize due events and publishes them in creation order.
+// It returns the number of events claimed.
+func (r *Relay) deliverBatch(ctx context.Context) (int, error) {
+	db := r.session(ctx)
+	claimedAt := time.Now()
+	token := uuid.New().String()
+
+	var ids []string
+	err := db.Model(&entity.OutboxEvent{}).
+		Where(entity.OutboxEventColumn.Status+" = ? AND "+entity.OutboxEventColumn.NextAttemptAt+" <= ?", entity.OutboxStatusPending, claimedAt).
+		Order(entity.OutboxEventColumn.NextAttemptAt).
+		Limit(r.cfg.BatchSize).
+		Pluck(entity.OutboxEventColumn.ID, &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	// The conditions are repeated, so events claimed meanwhile by another relay are skipped
+	err = db.Model(&entity.OutboxEvent{}).
+		Where(entity.OutboxEventColumn.ID+" IN ?", ids).
+		Where(entity.OutboxEventColumn.Status+" = ? AND "+entity.OutboxEventColumn.NextAttemptAt+" <= ?", entity.OutboxStatusPending, claimedAt).
+		Updates(map[string]interface{}{
+			entity.OutboxEventColumn.ClaimToken:    token,
+			entity.OutboxEventColumn.NextAttemptAt: claimedAt.Add(ClaimLease),
+		}).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var events []entity.OutboxEvent
+	err = db.Where(entity.OutboxEventColumn.ClaimToken+" = ? AND "+entity.OutboxEventColumn.Status+" = ?", token, entity.OutboxStatusPending).
+		Order(entity.OutboxEventColumn.CreatedAt + ", " + entity.OutboxEventColumn.ID).
+		Find(&events).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for i, event := range events {
+		// Stop before the lease runs out, when another relay may claim the events again;
+		// the rest is released for the next poll
+		if ctx.Err() != nil || time.Since(claimedAt) > ClaimLease/2 {
+			r.release(token, events[i:])
+			break
+		}
+		r.deliver(ctx, token, event)
+	}
+	return len(ids), nil
+}
+
+// deliver publishes event and records the outcome
+func (r *Relay) deliver(ctx context.Context, token string, event entity.OutboxEvent) {
+	log := logger.GetLogger().With(
+		zap.String("event_id", event.ID),
+		zap.String("event_type", event.EventType),
+		zap.String("dedupe_key", event.DedupeKey),
+	)
+	attempt := event.Attempts + 1
+
+	publishErr := r.publisher.Publish(ctx, Message{
+		ID:            event.ID,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		DedupeKey:     event.DedupeKey,
+		OccurredAt:    event.CreatedAt,
+		Attempt:       attempt,
+		Payload:       json.RawMessage(event.Payload),
+	})
+
+	updates := map[string]interface{}{
+		entity.OutboxEventColumn.Attempts:   attempt,
+		entity.OutboxEventColumn.ClaimToken: nil,
+	}
+	outcome := ""
+	switch {
+	case publishErr == nil:
+		now := time.Now()
+		updates[entity.OutboxEventColumn.Status] = entity.OutboxStatusDelivered
+		updates[entity.OutboxEventColumn.DeliveredAt] = now
+		updates[entity.OutboxEventColumn.LastError] = ""
+	case errors.Is(publishErr, context.Canceled) && ctx.Err() != nil:
+		// Shutting down: not a failure of the consumer, so the attempt does not count
+		updates[entity.OutboxEventColumn.Attempts] = event.Attempts
+		updates[entity.OutboxEventColumn.NextAttemptAt] = time.Now()
+	case attempt >= r.cfg.MaxAttempts:
+		outcome = "failed"
+		updates[entity.OutboxEventColumn.Status] = entity.OutboxStatusFailed
+		updates[entity.OutboxEventColumn.LastError] = truncate(publishErr.Error(), maxErrorLength)
+	default:
+		outcome = "retry"
+		updates[entity.OutboxEventColumn.NextAttemptAt] = time.Now().Add(r.retryDelay(attempt))
+		updates[entity.OutboxEventColumn.LastError] = truncate(publishErr.Error(), maxErrorLength)
+	}
+
+	// Recorded even when ctx is cancelled, so a delivered event is not sent again on restart
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	result := r.session(recordCtx).Model(&entity.OutboxEvent{}).
+		Where(entity.OutboxEventColumn.ID+" = ? AND "+entity.OutboxEventColumn.ClaimToken+" = ?", event.ID, token).
+		Updates(updates)
+	if result.Error != nil {
+		// The lease expires and the event is delivered again
+		log.Error("Failed to record outbox delivery", zap.Bool("published", publishErr == nil), zap.Error(result.Error))
+	} else if result.RowsAffected == 0 {
+		log.Warn("Outbox event claim lost before its delivery was recorded")
+	}
+
+	switch outcome {
+	case "":
+		if publishErr == nil {
+			metrics.OutboxEventsPublishedTotal.WithLabelValues(event.EventType).Inc()
+		}
+	case "failed":
+		metrics.OutboxPublishFailuresTotal.WithLabelValues(event.EventType, outcome).Inc()
+		log.Error("Outbox event delivery failed, giving up",
+			zap.Int("attempts", attempt),
+			zap.Error(publishErr),
+		)
+	default:
+		metrics.OutboxPublishFailuresTotal.WithLabelValues(event.EventType, outcome).Inc()
+		log.Warn("Outbox event delivery failed, will retry",
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", r.retryDelay(attempt)),
+			zap.Error(publishErr),
+		)
+	}
+}
+
+// retryDelay returns the delay after the given failed attempt: RetryBaseDelay doubled per
+// attempt, capped at RetryMaxDelaySeconds
+func (r *Relay) retryDelay(attempt int) time.Duration {
+	maxDelay := time.Duration(r.cfg.RetryMaxDelaySeconds) * time.Second
+	delay := RetryBaseDelay
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// release makes claimed events due again without counting an attempt
+func (r *Relay) release(token string, events []entity.OutboxEvent) {
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := r.session(ctx).Model(&entity.OutboxEvent{}).
+		Where(entity.OutboxEventColumn.ID+" IN ? AND "+entity.OutboxEventColumn.ClaimToken+" = ?", ids, token).
+		Updates(map[string]interface{}{
+			entity.OutboxEventColumn.ClaimToken:    nil,
+			entity.OutboxEventColumn.NextAttemptAt: time.Now(),
+		}).Error
+	if err != nil {
+		// They are claimed again once the lease expires
+		logger.GetLogger().Warn("Failed to release outbox events", zap.Int("events", len(ids)), zap.Error(err))
+	}
+}
+
+// cleanup deletes the events delivered more than RetentionDays ago. Failed events are kept
+// for inspection.
+func (r *Relay) cleanup(ctx context.Context) {
+	cutoff := time.Now().AddDate(0, 0, -r.cfg.RetentionDays)
+	result := r.session(ctx).
+		Where(entity.OutboxEventColumn.Status+" = ? AND "+entity.OutboxEventColumn.DeliveredAt+" < ?", entity.OutboxStatusDelivered, cutoff).
+		Delete(&entity.OutboxEvent{})
+	if result.Error != nil {
+		if ctx.Err() == nil {
+			logger.GetLogger().Error("Failed to delete delivered outbox events", zap.Time("cutoff", cutoff), zap.Error(result.Error))
+		}
+		return
+	}
+	if result.RowsAffected > 0 {
+		logger.GetLogger().Info("Deleted delivered outbox events",
+			zap.Time("cutoff", cutoff),
+			zap.Int64("events", result.RowsAffected),
+		)
+	}
+}
+
+func (r *Relay) updatePendingGauge(ctx context.Context) {
+	var pending int64
+	err := r.session(ctx).Model(&entity.OutboxEvent{}).
+		Where(entity.OutboxEventColumn.Status+" = ?", entity.OutboxStatusPending).
+		Count(&pending).Error
+	if err == nil {
+		metrics.OutboxPendingEvents.Set(float64(pending))
+	}
+}
+
+// session returns a new session on the primary: claims must see the latest state
+func (r *Relay) session(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(database.WithPrimary(ctx)).Session(&gorm.Session{})
+}
+
+// truncate returns s as valid UTF-8 without NUL bytes, cut to at most n bytes on a rune
+// boundary: PostgreSQL rejects anything else in a text column, and the outcome of the
+// delivery would then never be recorded
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(strings.ToValidUTF8(s, ""), "\x00", "")
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
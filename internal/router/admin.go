@@ -0,0 +1,242 @@
+package router
+
+import (
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"gorm.io/gorm"
+
+	"llm-aggregator/internal/config"
+	"llm-aggregator/internal/database"
+	"llm-aggregator/internal/lifecycle"
+	"llm-aggregator/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// startTime is used to report uptime in runtime stats
+var startTime = time.Now()
+
+// NewAdminRouter creates the engine for the admin listener.
+// It serves the operational endpoints, runtime stats and optionally pprof, without the
+// public middleware stack (CORS, rate limiting, request timeout).
+func NewAdminRouter(db *gorm.DB, cfg *config.Config, readiness *lifecycle.Readiness) *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.RequestID())
+	r.Use(middleware.Logging())
+	r.Use(middleware.Recovery())
+
+	registerOperationalRoutes(r, db, readiness)
+
+	// Runtime stats (goroutines, memory, GC)
+	r.GET("/debug/runtime", runtimeStats)
+
+	// Profiling endpoints, e.g. go tool pprof http://127.0.0.1:<admin port>/debug/pprof/heap
+	if cfg.Server.Admin.Pprof {
+		r.GET("/debug/pprof/*name", pprofHandler)
+		r.POST("/debug/pprof/*name", pprofHandler) // pprof symbol lookups use POST
+	}
+
+	return r
+}
+
+// registerOperationalRoutes mounts health, metrics, swagger and version endpoints.
+// readiness drives /health/ready; nil means always ready.
+func registerOperationalRoutes(r gin.IRouter, db *gorm.DB, readiness *lifecycle.Readiness) {
+	// Health check endpoints (skip middleware for faster response)
+	healthGroup := r.Group("")
+	{
+		// Readiness probe - checks if service is ready to accept traffic
+		// @Summary     Readiness probe
+		// @Description Check if the service is ready to accept traffic (database connection required)
+		// @Tags        health
+		// @Accept      json
+		// @Produce     json
+		// @Success     200  {object} map[string]interface{} "Service is ready"
+		// @Failure     503  {object} map[string]interface{} "Service is not ready"
+		// @Router      /health/ready [get]
+		healthGroup.GET("/health/ready", func(c *gin.Context) {
+			// Not ready while starting up or draining for shutdown
+			if !readiness.IsReady() {
+				c.JSON(503, gin.H{
+					"status":  "not_ready",
+					"message": "Service is starting up or shutting down",
+				})
+				return
+			}
+
+			// Check database connection
+			sqlDB, err := db.DB()
+			if err != nil {
+				c.JSON(503, gin.H{
+					"status":  "not_ready",
+					"message": "Database connection error",
+					"error":   err.Error(),
+				})
+				return
+			}
+
+			if err := sqlDB.Ping(); err != nil {
+				c.JSON(503, gin.H{
+					"status":  "not_ready",
+					"message": "Database ping failed",
+					"error":   err.Error(),
+				})
+				return
+			}
+
+			c.JSON(200, gin.H{
+				"status":  "ready",
+				"message": "Service is ready to accept traffic",
+			})
+		})
+
+		// Liveness probe - checks if service is alive
+		// @Summary     Liveness probe
+		// @Description Check if the service is alive (does not check database)
+		// @Tags        health
+		// @Accept      json
+		// @Produce     json
+		// @Success     200  {object} map[string]interface{} "Service is alive"
+		// @Router      /health/live [get]
+		healthGroup.GET("/health/live", func(c *gin.Context) {
+			c.JSON(200, gin.H{
+				"status":  "alive",
+				"message": "Service is running",
+			})
+		})
+
+		// Full health check with database status
+		// @Summary     Health check
+		// @Description Check if the API is healthy and database is connected; "degraded" when a read replica is unhealthy
+		// @Tags        health
+		// @Accept      json
+		// @Produce     json
+		// @Success     200  {object} map[string]interface{} "Health status with database info"
+		// @Failure     503  {object} map[string]interface{} "Service unavailable"
+		// @Router      /health [get]
+		healthGroup.GET("/health", func(c *gin.Context) {
+			// Check database connection
+			sqlDB, err := db.DB()
+			if err != nil {
+				c.JSON(503, gin.H{
+					"status":  "unhealthy",
+					"message": "Database connection error",
+					"error":   err.Error(),
+				})
+				return
+			}
+
+			if err := sqlDB.Ping(); err != nil {
+				c.JSON(503, gin.H{
+					"status":  "unhealthy",
+					"message": "Database ping failed",
+					"error":   err.Error(),
+				})
+				return
+			}
+
+			stats := sqlDB.Stats()
+			body := gin.H{
+				"status": "healthy",
+				"database": gin.H{
+					"status":           "connected",
+					"open_connections": stats.OpenConnections,
+					"in_use":           stats.InUse,
+					"idle":             stats.Idle,
+				},
+			}
+
+			// Unhealthy replicas degrade the service but do not fail it: reads fall back to the primary
+			if replicas := database.ReplicaStatuses(db); replicas != nil {
+				body["replicas"] = replicas
+				for _, replica := range replicas {
+					if !replica.Healthy {
+						body["status"] = "degraded"
+					}
+				}
+			}
+			c.JSON(200, body)
+		})
+	}
+
+	// Prometheus metrics endpoint
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Swagger documentation
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// Version endpoint
+	// @Summary     Get API version
+	// @Description Returns the API version information
+	// @Tags        system
+	// @Accept      json
+	// @Produce     json
+	// @Success     200  {object} map[string]interface{} "Version information"
+	// @Router      /version [get]
+	r.GET("/version", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"version":     "1.0.0",
+			"name":        "LLM Aggregator API",
+			"description": "LLM Aggregator - A production-ready Golang API following Clean Architecture and DDD principles",
+			"build_time":  "", // Can be set during build: -ldflags "-X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+			"git_commit":  "", // Can be set during build: -ldflags "-X main.gitCommit=$(git rev-parse HEAD)"
+		})
+	})
+}
+
+// pprofHandler dispatches /debug/pprof/* to net/http/pprof
+func pprofHandler(c *gin.Context) {
+	switch c.Param("name") {
+	case "/cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "/profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "/symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "/trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		// Index also serves named profiles such as /heap, /goroutine, /allocs
+		pprof.Index(c.Writer, c.Request)
+	}
+}
+
+// runtimeStats returns Go runtime statistics
+func runtimeStats(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	var lastPause uint64
+	if mem.NumGC > 0 {
+		lastPause = mem.PauseNs[(mem.NumGC+255)%256]
+	}
+
+	c.JSON(200, gin.H{
+		"go_version":     runtime.Version(),
+		"uptime_seconds": int64(time.Since(startTime).Seconds()),
+		"num_cpu":        runtime.NumCPU(),
+		"gomaxprocs":     runtime.GOMAXPROCS(0),
+		"goroutines":     runtime.NumGoroutine(),
+		"memory": gin.H{
+			"alloc_bytes":       mem.Alloc,
+			"total_alloc_bytes": mem.TotalAlloc,
+			"sys_bytes":         mem.Sys,
+			"heap_alloc_bytes":  mem.HeapAlloc,
+			"heap_inuse_bytes":  mem.HeapInuse,
+			"heap_objects":      mem.HeapObjects,
+			"stack_inuse_bytes": mem.StackInuse,
+		},
+		"gc": gin.H{
+			"num_gc":          mem.NumGC,
+			"pause_total_ns":  mem.PauseTotalNs,
+			"last_pause_ns":   lastPause,
+			"next_gc_bytes":   mem.NextGC,
+			"gc_cpu_fraction": mem.GCCPUFraction,
+		},
+	})
+}
@@ -0,0 +1,36 @@
+package router
+
+import (
+	"time"
+
+	"llm-aggregator/internal/config"
+	"llm-aggregator/internal/middleware"
+)
+
+// Tunables holds the live middleware settings that can be changed without a restart.
+// The same instance is used by the router middlewares and by the config reloader.
+type Tunables struct {
+	RateLimiter    *middleware.RateLimiter
+	CORSOrigins    *middleware.OriginList
+	RequestTimeout *middleware.TimeoutValue
+}
+
+// NewTunables creates tunables initialized from cfg
+func NewTunables(cfg *config.Config) *Tunables {
+	return &Tunables{
+		RateLimiter:    middleware.NewRateLimiter(cfg.ServerLimits.RateLimitRPS, cfg.ServerLimits.RateLimitBurst),
+		CORSOrigins:    middleware.NewOriginList(middleware.ParseAllowedOrigins(cfg.Server.CORSOrigins)),
+		RequestTimeout: middleware.NewTimeoutValue(requestTimeout(cfg)),
+	}
+}
+
+// Apply swaps the runtime-tunable values from cfg into the live middlewares
+func (t *Tunables) Apply(cfg *config.Config) {
+	t.RateLimiter.SetLimit(cfg.ServerLimits.RateLimitRPS, cfg.ServerLimits.RateLimitBurst)
+	t.CORSOrigins.Set(middleware.ParseAllowedOrigins(cfg.Server.CORSOrigins))
+	t.RequestTimeout.Set(requestTimeout(cfg))
+}
+
+func requestTimeout(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.ServerLimits.RequestTimeoutSeconds) * time.Second
+}
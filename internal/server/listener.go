@@ -0,0 +1,102 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"llm-aggregator/internal/config"
+)
+
+// systemdFirstFD is the first file descriptor passed by systemd socket activation
+const systemdFirstFD = 3
+
+// newListener creates the public listener for the configured network.
+// For Unix sockets it also returns the socket path, which must be removed on shutdown.
+func newListener(cfg config.ServerConfig) (net.Listener, string, error) {
+	switch cfg.Network {
+	case "unix":
+		ln, err := listenUnix(cfg)
+		return ln, cfg.SocketPath, err
+	case "systemd":
+		ln, err := systemdListener()
+		return ln, "", err
+	default:
+		ln, err := net.Listen("tcp", fmt.Sprintf("%s:%s", cfg.Host, cfg.Port))
+		return ln, "", err
+	}
+}
+
+// listenUnix listens on cfg.SocketPath with cfg.SocketMode permissions.
+// A stale socket file left by a crashed process is removed first.
+func listenUnix(cfg config.ServerConfig) (net.Listener, error) {
+	mode, err := cfg.SocketFileMode()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := removeStaleSocket(cfg.SocketPath); err != nil {
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", cfg.SocketPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(cfg.SocketPath, mode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return ln, nil
+}
+
+// removeStaleSocket removes path if it is a socket nobody is listening on.
+// It refuses to remove regular files or sockets still in use.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %s is already in use", path)
+	}
+	return os.Remove(path)
+}
+
+// systemdListener returns the first listener passed by systemd socket activation
+// (LISTEN_PID/LISTEN_FDS, see sd_listen_fds(3)). Only one socket is used; additional
+// sockets are ignored.
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, errors.New("no socket passed by systemd: LISTEN_PID is not set to this process")
+	}
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds < 1 {
+		return nil, errors.New("no socket passed by systemd: LISTEN_FDS is not set")
+	}
+
+	// Child processes must not inherit the sockets
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	file := os.NewFile(uintptr(systemdFirstFD), "systemd-socket")
+	defer file.Close() // FileListener dups the descriptor
+
+	ln, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use systemd socket: %w", err)
+	}
+	return ln, nil
+}
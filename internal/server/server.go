@@ -2,8 +2,11 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
 	"time"
 
 	"llm-aggregator/internal/config"
@@ -11,27 +14,147 @@ import (
 )
 
 type Server struct {
-	httpServer *http.Server
+	httpServer    *http.Server
+	listener      net.Listener  // Public listener: TCP, Unix socket or inherited from systemd
+	socketPath    string        // Unix socket file removed on shutdown, empty otherwise
+	adminServer   *http.Server  // nil when the admin listener is disabled
+	adminListener net.Listener  // nil when the admin listener is disabled
+	certs         *certReloader // nil when TLS is disabled
+	watchCtx      context.Context
+	stopWatch     context.CancelFunc
 }
 
-func NewServer(cfg config.ServerConfig, router *gin.Engine) *Server {
-	return &Server{
-		httpServer: &http.Server{
-			Addr:           fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
-			Handler:        router,
-			ReadTimeout:    15 * time.Second,
-			WriteTimeout:   15 * time.Second,
-			IdleTimeout:    60 * time.Second,
-			MaxHeaderBytes: 1 << 20, // 1 MB
-		},
+// NewServer creates the public server and, if adminRouter is not nil, the admin server.
+// Both listeners are opened immediately so address and socket errors are reported here.
+// The admin listener uses plain HTTP over TCP and is meant for internal access only.
+func NewServer(cfg config.ServerConfig, router *gin.Engine, adminRouter *gin.Engine) (*Server, error) {
+	httpServer := &http.Server{
+		Addr:              fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
+		Handler:           router,
+		ReadTimeout:       seconds(cfg.ReadTimeoutSeconds),
+		ReadHeaderTimeout: seconds(cfg.ReadHeaderTimeoutSeconds),
+		WriteTimeout:      seconds(cfg.WriteTimeoutSeconds),
+		IdleTimeout:       seconds(cfg.IdleTimeoutSeconds),
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
 	}
+	httpServer.SetKeepAlivesEnabled(cfg.KeepAlive)
+
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	s := &Server{
+		httpServer: httpServer,
+		watchCtx:   watchCtx,
+		stopWatch:  stopWatch,
+	}
+
+	if cfg.TLS.Enabled {
+		certs, err := newCertReloader(cfg.TLS)
+		if err != nil {
+			stopWatch()
+			return nil, err
+		}
+		s.certs = certs
+		httpServer.TLSConfig = certs.TLSConfig()
+	}
+
+	listener, socketPath, err := newListener(cfg)
+	if err != nil {
+		stopWatch()
+		return nil, fmt.Errorf("failed to listen on %s: %w", cfg.ListenAddress(), err)
+	}
+	s.listener = listener
+	s.socketPath = socketPath
+
+	if adminRouter != nil {
+		s.adminServer = &http.Server{
+			Addr:              fmt.Sprintf("%s:%s", cfg.Admin.Host, cfg.Admin.Port),
+			Handler:           adminRouter,
+			ReadHeaderTimeout: seconds(cfg.ReadHeaderTimeoutSeconds),
+			IdleTimeout:       seconds(cfg.IdleTimeoutSeconds),
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+			// No read/write timeout: CPU profiles and traces stream for as long as requested
+		}
+		adminListener, err := net.Listen("tcp", s.adminServer.Addr)
+		if err != nil {
+			stopWatch()
+			_ = listener.Close()
+			_ = s.removeSocket()
+			return nil, fmt.Errorf("failed to listen on %s: %w", s.adminServer.Addr, err)
+		}
+		s.adminListener = adminListener
+	}
+
+	return s, nil
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
 }
 
+// Start runs the public and admin listeners and blocks until one of them stops.
+// If either listener fails, the other one is closed too. It returns nil after Shutdown.
 func (s *Server) Start() error {
-	fmt.Printf("Server starting on %s\n", s.httpServer.Addr)
-	return s.httpServer.ListenAndServe()
+	errCh := make(chan error, 2)
+
+	if s.adminServer != nil {
+		go func() {
+			fmt.Printf("Admin server starting on %s\n", s.adminServer.Addr)
+			errCh <- s.adminServer.Serve(s.adminListener)
+		}()
+	}
+
+	go func() {
+		addr := s.listener.Addr()
+		if s.certs == nil {
+			fmt.Printf("Server starting on %s:%s\n", addr.Network(), addr)
+			errCh <- s.httpServer.Serve(s.listener)
+			return
+		}
+
+		go s.certs.watch(s.watchCtx)
+
+		fmt.Printf("Server starting on %s:%s (TLS)\n", addr.Network(), addr)
+		// Certificates come from TLSConfig, so no files are passed here
+		errCh <- s.httpServer.ServeTLS(s.listener, "", "")
+	}()
+
+	err := <-errCh
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	s.close()
+	return err
 }
 
+// Shutdown gracefully stops both listeners, waiting for in-flight requests until ctx expires
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+	s.stopWatch()
+
+	var errs []error
+	if s.adminServer != nil {
+		errs = append(errs, s.adminServer.Shutdown(ctx))
+	}
+	errs = append(errs, s.httpServer.Shutdown(ctx))
+	errs = append(errs, s.removeSocket())
+	return errors.Join(errs...)
+}
+
+// removeSocket deletes the Unix socket file so the next start can bind the same path
+func (s *Server) removeSocket() error {
+	if s.socketPath == "" {
+		return nil
+	}
+	if err := os.Remove(s.socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove socket file: %w", err)
+	}
+	return nil
+}
+
+// close stops both listeners immediately
+func (s *Server) close() {
+	s.stopWatch()
+	if s.adminServer != nil {
+		_ = s.adminServer.Close()
+	}
+	_ = s.httpServer.Close()
+	_ = s.removeSocket()
 }
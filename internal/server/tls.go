@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"llm-aggregator/internal/config"
+	"llm-aggregator/internal/logger"
+)
+
+// tlsVersions maps config min_version values to crypto/tls constants
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// clientAuthTypes maps config client_auth values to crypto/tls constants
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// certReloader keeps the TLS config built from the certificate, key and client CA files
+// and rebuilds it when any of the files change on disk. Connections already established
+// keep their certificate; new handshakes use the latest one.
+type certReloader struct {
+	cfg     config.TLSConfig
+	current atomic.Pointer[tls.Config]
+	stamps  string
+}
+
+func newCertReloader(cfg config.TLSConfig) (*certReloader, error) {
+	r := &certReloader{cfg: cfg}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig returns the config used by the http.Server.
+// Every handshake picks up the latest loaded certificate and client CA pool.
+func (r *certReloader) TLSConfig() *tls.Config {
+	base := r.current.Load()
+	return &tls.Config{
+		MinVersion: base.MinVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current.Load(), nil
+		},
+	}
+}
+
+// reload reads the files and swaps the active TLS config.
+// On error the previous config stays active.
+func (r *certReloader) reload() error {
+	stamps := r.fileStamps()
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	tlsCfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tlsVersions[r.cfg.MinVersion],
+		ClientAuth:   clientAuthTypes[r.cfg.ClientAuth],
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	for _, name := range r.cfg.CipherSuites {
+		id, _ := config.TLSCipherSuite(name)
+		tlsCfg.CipherSuites = append(tlsCfg.CipherSuites, id)
+	}
+
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("client CA file contains no valid PEM certificates")
+		}
+		tlsCfg.ClientCAs = pool
+	}
+
+	r.current.Store(tlsCfg)
+	r.stamps = stamps
+	return nil
+}
+
+// fileStamps returns a value that changes when any of the TLS files change
+func (r *certReloader) fileStamps() string {
+	return config.FileStamp(r.cfg.CertFile) + "|" + config.FileStamp(r.cfg.KeyFile) + "|" + config.FileStamp(r.cfg.ClientCAFile)
+}
+
+// watch polls the files every reload_interval_seconds and reloads them on change.
+// It blocks until ctx is cancelled.
+func (r *certReloader) watch(ctx context.Context) {
+	if r.cfg.ReloadIntervalSeconds <= 0 {
+		return
+	}
+	ticker := time.NewTicker(seconds(r.cfg.ReloadIntervalSeconds))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if r.fileStamps() == r.stamps {
+				continue
+			}
+			// Cert and key are often replaced one after the other; a mismatched pair
+			// fails to load and is retried on the next tick
+			if err := r.reload(); err != nil {
+				logger.GetLogger().Error("TLS certificate reload failed, keeping current certificate", zap.Error(err))
+				continue
+			}
+			logger.GetLogger().Info("TLS certificate reloaded", zap.String("cert_file", r.cfg.CertFile))
+		}
+	}
+}
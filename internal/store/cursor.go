@@ -0,0 +1,242 @@
+package store
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidCursor is returned for a cursor that is malformed, not signed with the current key,
+// or made for another table or sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor directions
+const (
+	cursorNext = "next" // Rows after the cursor row
+	cursorPrev = "prev" // Rows before the cursor row
+)
+
+var (
+	cursorKeyMu sync.RWMutex
+	cursorKey   = randomCursorKey()
+)
+
+// SetCursorKey sets the key signing pagination cursors. Instances serving the same clients must
+// share it; without it, each process signs with a random key and cursors do not survive a restart.
+func SetCursorKey(key []byte) {
+	cursorKeyMu.Lock()
+	defer cursorKeyMu.Unlock()
+	cursorKey = append([]byte(nil), key...)
+}
+
+func randomCursorKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("store: failed to generate cursor key: " + err.Error())
+	}
+	return key
+}
+
+// cursor is the position of a row in a keyset ordering, sent to clients as an opaque token
+type cursor struct {
+	Sort      string          `json:"s"` // "<table>.<field> <ASC|DESC>", so a cursor is not reused with another list or order
+	Direction string          `json:"d"` // cursorNext or cursorPrev
+	Key       json.RawMessage `json:"k"` // Sort field value of the row
+	ID        json.RawMessage `json:"i"` // Primary key of the row, to break ties
+}
+
+// encode returns the cursor as base64url(JSON) "." base64url(HMAC-SHA256)
+func (c cursor) encode() (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signCursor(encoded)), nil
+}
+
+func decodeCursor(token string) (cursor, error) {
+	var c cursor
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return c, ErrInvalidCursor
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sum, signCursor(encoded)) {
+		return c, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || json.Unmarshal(payload, &c) != nil {
+		return c, ErrInvalidCursor
+	}
+	if c.Direction != cursorNext && c.Direction != cursorPrev {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+func signCursor(encoded string) []byte {
+	cursorKeyMu.RLock()
+	mac := hmac.New(sha256.New, cursorKey)
+	cursorKeyMu.RUnlock()
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// CursorPage holds the cursors around a page fetched with Query.FindPage
+type CursorPage struct {
+	NextCursor string // Empty when there is no row after the page
+	PrevCursor string // Empty on the first page
+}
+
+// keyset is the keyset pagination state of a Query
+type keyset struct {
+	field     string
+	direction string // ASC or DESC
+	token     string // Cursor from the client, empty for the first page
+	size      int
+}
+
+// Keyset switches the query to keyset (cursor) pagination: rows are ordered by field, then by
+// primary key, in direction, and FindPage returns size rows after (or before) the row of the
+// cursor. Unlike Page, deep pages cost the same as the first, and rows inserted meanwhile do not
+// shift pages. field must be a non-null column; an empty token fetches the first page.
+//
+// Usage:
+//   page, err := store.NewQuery[entity.Order](db).
+//       Eq("user_id", userID).
+//       Keyset("created_at", "DESC", req.Cursor, req.Limit).
+//       FindPage(&orders)
+func (q *Query[T]) Keyset(field, direction, token string, size int) *Query[T] {
+	direction = strings.ToUpper(direction)
+	if direction != "ASC" && direction != "DESC" {
+		direction = "ASC"
+	}
+	if size < 1 {
+		size = 20
+	}
+	q.keyset = &keyset{field: field, direction: direction, token: token, size: size}
+	return q
+}
+
+// FindPage finds the page set by Keyset into dest and returns the cursors of the pages around it.
+// It returns ErrInvalidCursor when the token of Keyset cannot be used.
+func (q *Query[T]) FindPage(dest *[]T) (*CursorPage, error) {
+	ks := q.keyset
+	if ks == nil {
+		return nil, errors.New("store: FindPage requires Keyset")
+	}
+	if !isValidFieldName(ks.field) {
+		return nil, fmt.Errorf("store: invalid keyset field %q", ks.field)
+	}
+	if _, ordered := q.db.Statement.Clauses["ORDER BY"]; ordered {
+		return nil, errors.New("store: FindPage cannot be combined with Order or OrderBy")
+	}
+	sch, err := schema.Parse(&q.model, &sync.Map{}, q.db.NamingStrategy)
+	if err != nil {
+		return nil, err
+	}
+	sortField, idField := sch.LookUpField(ks.field), sch.PrioritizedPrimaryField
+	if sortField == nil || idField == nil {
+		return nil, fmt.Errorf("store: keyset field %q or primary key not found", ks.field)
+	}
+	sort := sch.Table + "." + ks.field + " " + ks.direction
+
+	// Fetch one extra row to know whether there is more in the fetch direction
+	query := q.db
+	backward := false
+	if ks.token != "" {
+		c, err := decodeCursor(ks.token)
+		if err != nil || c.Sort != sort {
+			return nil, ErrInvalidCursor
+		}
+		key, id := reflect.New(sortField.FieldType), reflect.New(idField.FieldType)
+		if json.Unmarshal(c.Key, key.Interface()) != nil || json.Unmarshal(c.ID, id.Interface()) != nil {
+			return nil, ErrInvalidCursor
+		}
+		backward = c.Direction == cursorPrev
+		// Rows after the cursor row in fetch order: (field, id) > (key, id) ascending, < descending
+		op := ">"
+		if (ks.direction == "DESC") != backward {
+			op = "<"
+		}
+		query = query.Where(fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND %[3]s %[2]s ?))", ks.field, op, idField.DBName),
+			key.Elem().Interface(), key.Elem().Interface(), id.Elem().Interface())
+	}
+	fetchDirection := ks.direction
+	if backward {
+		fetchDirection = map[string]string{"ASC": "DESC", "DESC": "ASC"}[ks.direction]
+	}
+	err = query.
+		Clauses(clause.OrderBy{Columns: []clause.OrderByColumn{
+			{Column: clause.Column{Name: ks.field, Raw: true}, Desc: fetchDirection == "DESC"},
+			{Column: clause.Column{Name: idField.DBName}, Desc: fetchDirection == "DESC"},
+		}}).
+		Limit(ks.size + 1).
+		Find(dest).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rows := *dest
+	more := len(rows) > ks.size
+	if more {
+		rows = rows[:ks.size]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	*dest = rows
+
+	page := &CursorPage{}
+	if len(rows) == 0 {
+		return page, nil
+	}
+	// Going forward, a previous page exists when a cursor was given; going backward, a next
+	// page always exists (the one the cursor came from)
+	hasNext, hasPrev := more, ks.token != ""
+	if backward {
+		hasNext, hasPrev = true, more
+	}
+	ctx := q.db.Statement.Context
+	if hasNext {
+		if page.NextCursor, err = rowCursor(ctx, sort, cursorNext, sortField, idField, &rows[len(rows)-1]); err != nil {
+			return nil, err
+		}
+	}
+	if hasPrev {
+		if page.PrevCursor, err = rowCursor(ctx, sort, cursorPrev, sortField, idField, &rows[0]); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// rowCursor returns the cursor of row
+func rowCursor(ctx context.Context, sort, direction string, sortField, idField *schema.Field, row interface{}) (string, error) {
+	value := reflect.ValueOf(row).Elem()
+	key, _ := sortField.ValueOf(ctx, value)
+	id, _ := idField.ValueOf(ctx, value)
+	encodedKey, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	encodedID, err := json.Marshal(id)
+	if err != nil {
+		return "", err
+	}
+	return cursor{Sort: sort, Direction: direction, Key: encodedKey, ID: encodedID}.encode()
+}
@@ -0,0 +1,263 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType is the type the query string values of a Field are converted to
+type FieldType int
+
+const (
+	FieldString FieldType = iota
+	FieldInt
+	FieldFloat
+	FieldBool
+	FieldTime // RFC 3339 time, or a date (2006-01-02, local midnight)
+)
+
+// Filter operators, as in filter[<field>][<operator>]=<value>
+const (
+	OpEq   = "eq" // Default when the operator is omitted
+	OpNe   = "ne"
+	OpGt   = "gt"
+	OpGte  = "gte"
+	OpLt   = "lt"
+	OpLte  = "lte"
+	OpIn   = "in"   // Comma-separated values
+	OpLike = "like" // Contains, strings only
+)
+
+// maxInValues bounds the values of an in filter
+const maxInValues = 100
+
+// filterParamPattern matches filter[<field>] and filter[<field>][<operator>]
+var filterParamPattern = regexp.MustCompile(`^filter\[([^\[\]]+)\](?:\[([^\[\]]+)\])?$`)
+
+// operators returns the filter operators allowed on a field of type t
+func (t FieldType) operators() []string {
+	switch t {
+	case FieldString:
+		return []string{OpEq, OpNe, OpIn, OpLike}
+	case FieldBool:
+		return []string{OpEq, OpNe}
+	}
+	return []string{OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn}
+}
+
+// parse converts a query string value to t
+func (t FieldType) parse(raw string) (any, error) {
+	switch t {
+	case FieldInt:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", raw)
+		}
+		return v, nil
+	case FieldFloat:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("%q is not a number", raw)
+		}
+		return v, nil
+	case FieldBool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", raw)
+		}
+		return v, nil
+	case FieldTime:
+		v, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			if v, err = time.ParseInLocation(time.DateOnly, raw, time.Local); err != nil {
+				return nil, fmt.Errorf("%q is not an RFC 3339 time or a date (2006-01-02)", raw)
+			}
+		}
+		// Timestamps are stored in local time (autoCreateTime), and SQLite compares them as text
+		return v.Local(), nil
+	}
+	return raw, nil
+}
+
+// Field is a column a list endpoint can be filtered and sorted by
+type Field struct {
+	Column string
+	Type   FieldType
+}
+
+// Fields is the whitelist of a list endpoint: the fields of filter[...] and sort, by query
+// string name (the JSON name, e.g. "createdAt")
+type Fields map[string]Field
+
+// names returns the sorted field names, for error messages
+func (f Fields) names() string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Filter is one condition of a ListQuery
+type Filter struct {
+	Column string
+	Op     string
+	Value  any // []any for OpIn
+}
+
+// Sort is one ORDER BY column of a ListQuery
+type Sort struct {
+	Column    string
+	Direction string // ASC or DESC
+}
+
+// ListQuery is the filters and sort order of a list endpoint, parsed by ParseListQuery
+// and applied with Query.Apply
+type ListQuery struct {
+	Filters []Filter
+	Sorts   []Sort
+}
+
+// ListQueryError is a query string parameter rejected by ParseListQuery
+type ListQueryError struct {
+	Param   string // e.g. "filter[amount][gte]" or "sort"
+	Message string
+}
+
+func (e *ListQueryError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Param, e.Message)
+}
+
+// ParseListQuery parses the filter and sort parameters of a list endpoint query string:
+//
+//	filter[<field>][<operator>]=<value>  operator eq (default), ne, gt, gte, lt, lte, in or like
+//	sort=<field>,-<field>                ascending, or descending with "-"
+//
+// Fields outside fields are rejected, and values are converted to the field type.
+// Conditions are ANDed; repeating a parameter adds a condition. Other parameters are ignored.
+// The error is a *ListQueryError naming the offending parameter.
+//
+// Usage:
+//   list, err := store.ParseListQuery(c.Request.URL.Query(), dto.OrderListFields)
+//   // ?filter[status][in]=1,2&filter[amount][gte]=10&sort=-createdAt,productName
+func ParseListQuery(values url.Values, fields Fields) (*ListQuery, error) {
+	list := &ListQuery{}
+
+	// Sorted, so the first offending parameter is always the same
+	var params []string
+	for param := range values {
+		if strings.HasPrefix(param, "filter[") {
+			params = append(params, param)
+		}
+	}
+	sort.Strings(params)
+
+	for _, param := range params {
+		match := filterParamPattern.FindStringSubmatch(param)
+		if match == nil {
+			return nil, &ListQueryError{Param: param, Message: "expected filter[<field>] or filter[<field>][<operator>]"}
+		}
+		name, op := match[1], match[2]
+		if op == "" {
+			op = OpEq
+		}
+		field, ok := fields[name]
+		if !ok {
+			return nil, &ListQueryError{Param: param, Message: fmt.Sprintf("unknown field %q, expected one of %s", name, fields.names())}
+		}
+		if operators := field.Type.operators(); !slices.Contains(operators, op) {
+			return nil, &ListQueryError{
+				Param:   param,
+				Message: fmt.Sprintf("operator %q is not supported on %s, expected one of %s", op, name, strings.Join(operators, ", ")),
+			}
+		}
+		for _, raw := range values[param] {
+			value, err := parseFilterValue(field.Type, op, raw)
+			if err != nil {
+				return nil, &ListQueryError{Param: param, Message: err.Error()}
+			}
+			list.Filters = append(list.Filters, Filter{Column: field.Column, Op: op, Value: value})
+		}
+	}
+
+	if raw := values.Get("sort"); raw != "" {
+		seen := make(map[string]bool)
+		for _, name := range strings.Split(raw, ",") {
+			name = strings.TrimSpace(name)
+			direction := "ASC"
+			if strings.HasPrefix(name, "-") {
+				name, direction = name[1:], "DESC"
+			}
+			field, ok := fields[name]
+			if !ok {
+				return nil, &ListQueryError{Param: "sort", Message: fmt.Sprintf("unknown field %q, expected one of %s", name, fields.names())}
+			}
+			if seen[name] {
+				return nil, &ListQueryError{Param: "sort", Message: fmt.Sprintf("field %q is given more than once", name)}
+			}
+			seen[name] = true
+			list.Sorts = append(list.Sorts, Sort{Column: field.Column, Direction: direction})
+		}
+	}
+
+	return list, nil
+}
+
+func parseFilterValue(t FieldType, op, raw string) (any, error) {
+	if op != OpIn {
+		return t.parse(raw)
+	}
+	items := strings.Split(raw, ",")
+	if len(items) > maxInValues {
+		return nil, fmt.Errorf("at most %d values are allowed", maxInValues)
+	}
+	values := make([]any, 0, len(items))
+	for _, item := range items {
+		value, err := t.parse(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// Apply adds the filters and sort order of list to the query; a nil list adds nothing.
+// Orderings added afterwards (e.g. a default order) only break ties.
+func (q *Query[T]) Apply(list *ListQuery) *Query[T] {
+	if list == nil {
+		return q
+	}
+	for _, filter := range list.Filters {
+		switch filter.Op {
+		case OpEq:
+			q.Eq(filter.Column, filter.Value)
+		case OpNe:
+			q.Ne(filter.Column, filter.Value)
+		case OpGt:
+			q.Gt(filter.Column, filter.Value)
+		case OpGte:
+			q.Gte(filter.Column, filter.Value)
+		case OpLt:
+			q.Lt(filter.Column, filter.Value)
+		case OpLte:
+			q.Lte(filter.Column, filter.Value)
+		case OpIn:
+			q.In(filter.Column, filter.Value.([]any))
+		case OpLike:
+			q.Like(filter.Column, filter.Value.(string))
+		}
+	}
+	for _, s := range list.Sorts {
+		q.OrderBy(s.Column, s.Direction)
+	}
+	return q
+}
@@ -22,15 +22,16 @@ func isValidFieldName(field string) bool {
 	if !validFieldNamePattern.MatchString(field) {
 		return false
 	}
-	// Prevent SQL keywords
+	// Prevent SQL keywords, as whole names only: created_at or deleted_at are valid fields
 	sqlKeywords := []string{
 		"SELECT", "INSERT", "UPDATE", "DELETE", "DROP", "CREATE", "ALTER",
-		"EXEC", "EXECUTE", "UNION", "SCRIPT", "--", "/*", "*/",
+		"EXEC", "EXECUTE", "UNION", "SCRIPT",
 	}
-	upperField := strings.ToUpper(field)
-	for _, keyword := range sqlKeywords {
-		if strings.Contains(upperField, keyword) {
-			return false
+	for _, part := range strings.Split(strings.ToUpper(field), ".") {
+		for _, keyword := range sqlKeywords {
+			if part == keyword {
+				return false
+			}
 		}
 	}
 	return true
@@ -41,6 +42,7 @@ type Query[T any] struct {
 	model  T
 	limit  int
 	offset int
+	keyset *keyset // Set by Keyset
 }
 
 func NewQuery[T any](db *gorm.DB) *Query[T] {
@@ -58,6 +60,46 @@ func (q *Query[T]) Eq(field string, v any) *Query[T] {
 	return q
 }
 
+// Ne filters rows whose field is not v
+func (q *Query[T]) Ne(field string, v any) *Query[T] {
+	if v != nil && isValidFieldName(field) {
+		q.db = q.db.Where(fmt.Sprintf("%s <> ?", field), v)
+	}
+	return q
+}
+
+// Gt filters rows whose field is greater than v
+func (q *Query[T]) Gt(field string, v any) *Query[T] {
+	if v != nil && isValidFieldName(field) {
+		q.db = q.db.Where(fmt.Sprintf("%s > ?", field), v)
+	}
+	return q
+}
+
+// Gte filters rows whose field is greater than or equal to v
+func (q *Query[T]) Gte(field string, v any) *Query[T] {
+	if v != nil && isValidFieldName(field) {
+		q.db = q.db.Where(fmt.Sprintf("%s >= ?", field), v)
+	}
+	return q
+}
+
+// Lt filters rows whose field is less than v
+func (q *Query[T]) Lt(field string, v any) *Query[T] {
+	if v != nil && isValidFieldName(field) {
+		q.db = q.db.Where(fmt.Sprintf("%s < ?", field), v)
+	}
+	return q
+}
+
+// Lte filters rows whose field is less than or equal to v
+func (q *Query[T]) Lte(field string, v any) *Query[T] {
+	if v != nil && isValidFieldName(field) {
+		q.db = q.db.Where(fmt.Sprintf("%s <= ?", field), v)
+	}
+	return q
+}
+
 func (q *Query[T]) Like(field string, v string) *Query[T] {
 	if v != "" && isValidFieldName(field) {
 		q.db = q.db.Where(fmt.Sprintf("%s LIKE ?", field), "%"+v+"%")
@@ -79,6 +121,14 @@ func (q *Query[T]) Between(field string, from any, to any) *Query[T] {
 	return q
 }
 
+// Unscoped includes soft-deleted rows when include is true
+func (q *Query[T]) Unscoped(include bool) *Query[T] {
+	if include {
+		q.db = q.db.Unscoped()
+	}
+	return q
+}
+
 func (q *Query[T]) Order(expr string) *Query[T] {
 	if expr != "" {
 		q.db = q.db.Order(expr)
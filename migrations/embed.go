@@ -0,0 +1,25 @@
+// Package migrations embeds the SQL schema migrations into the binary.
+//
+// Each database driver has its own directory (mysql, postgres, sqlite), as DDL differs between
+// dialects. Files are named <version>_<name>.up.sql and <version>_<name>.down.sql, where version
+// is numeric (the UTC timestamp generated by `app migrate create <name>`).
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+// FS holds every migration file, one directory per driver
+//
+//go:embed mysql postgres sqlite
+var FS embed.FS
+
+// ForDriver returns the migrations of a database driver
+func ForDriver(driver string) (fs.FS, error) {
+	if _, err := fs.Stat(FS, driver); err != nil {
+		return nil, fmt.Errorf("no embedded migrations for driver %q", driver)
+	}
+	return fs.Sub(FS, driver)
+}
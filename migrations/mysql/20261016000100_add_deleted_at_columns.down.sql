@@ -0,0 +1,2 @@
+ALTER TABLE orders DROP INDEX idx_orders_deleted_at, DROP COLUMN deleted_at;
+ALTER TABLE users DROP INDEX idx_users_deleted_at, DROP COLUMN deleted_at;
@@ -0,0 +1,3 @@
+-- Soft delete: rows with deleted_at set are hidden and purged after the retention period
+ALTER TABLE users ADD COLUMN deleted_at DATETIME(3) NULL, ADD INDEX idx_users_deleted_at (deleted_at);
+ALTER TABLE orders ADD COLUMN deleted_at DATETIME(3) NULL, ADD INDEX idx_orders_deleted_at (deleted_at);
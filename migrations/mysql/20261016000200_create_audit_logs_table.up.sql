@@ -0,0 +1,14 @@
+-- Audit trail of the creates, updates and deletes of users and orders
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id VARCHAR(36) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(36) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    request_id VARCHAR(64),
+    old_values TEXT,
+    new_values TEXT,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_audit_logs_entity (entity_type, entity_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,19 @@
+-- Transactional outbox: domain events written with the change they describe, delivered by the relay
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id VARCHAR(36) NOT NULL,
+    dedupe_key VARCHAR(255) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(3) NOT NULL,
+    claim_token VARCHAR(36),
+    last_error TEXT,
+    created_at DATETIME(3) NULL,
+    delivered_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_outbox_events_dedupe_key (dedupe_key),
+    INDEX idx_outbox_events_pending (status, next_attempt_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
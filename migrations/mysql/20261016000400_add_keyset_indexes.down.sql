@@ -0,0 +1,2 @@
+ALTER TABLE orders DROP INDEX idx_orders_created_at_id;
+ALTER TABLE users DROP INDEX idx_users_created_at_id;
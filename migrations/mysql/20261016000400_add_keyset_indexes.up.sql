@@ -0,0 +1,3 @@
+-- Keyset (cursor) pagination of the user and order lists: ORDER BY created_at, id
+ALTER TABLE users ADD INDEX idx_users_created_at_id (created_at, id);
+ALTER TABLE orders ADD INDEX idx_orders_created_at_id (created_at, id);
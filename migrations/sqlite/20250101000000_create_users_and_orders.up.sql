@@ -0,0 +1,24 @@
+-- Baseline schema, equivalent to the tables created by AutoMigrate
+CREATE TABLE IF NOT EXISTS users (
+    id VARCHAR(36),
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    status INT DEFAULT 1,
+    created_at DATETIME,
+    updated_at DATETIME,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id VARCHAR(36),
+    user_id VARCHAR(36) NOT NULL,
+    product_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL DEFAULT 1,
+    amount DECIMAL(10,2) NOT NULL,
+    status INT DEFAULT 1,
+    created_at DATETIME,
+    updated_at DATETIME,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
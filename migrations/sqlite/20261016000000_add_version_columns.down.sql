@@ -0,0 +1,3 @@
+-- DROP COLUMN requires SQLite 3.35+
+ALTER TABLE orders DROP COLUMN version;
+ALTER TABLE users DROP COLUMN version;
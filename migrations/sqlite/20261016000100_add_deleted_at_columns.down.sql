@@ -0,0 +1,5 @@
+-- DROP COLUMN requires SQLite 3.35+
+DROP INDEX IF EXISTS idx_orders_deleted_at;
+ALTER TABLE orders DROP COLUMN deleted_at;
+DROP INDEX IF EXISTS idx_users_deleted_at;
+ALTER TABLE users DROP COLUMN deleted_at;
@@ -0,0 +1,5 @@
+-- Soft delete: rows with deleted_at set are hidden and purged after the retention period
+ALTER TABLE users ADD COLUMN deleted_at DATETIME;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+ALTER TABLE orders ADD COLUMN deleted_at DATETIME;
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders (deleted_at);